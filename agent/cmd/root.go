@@ -7,12 +7,12 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
 
 	"github.com/d-led/ex_gocd/agent/internal/agent"
 	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/internal/registration"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var rootCmd = &cobra.Command{
@@ -57,34 +57,28 @@ func runAgent(cmd *cobra.Command, args []string) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	// Renew the agent's client certificate in the background before it expires.
+	renewer := registration.NewRenewer(agt.Registrar(), agt.Reload)
+	go renewer.Start(ctx)
 
-	// Start agent in goroutine
-	errChan := make(chan error, 1)
-	go func() {
-		errChan <- agt.Start(ctx)
-	}()
+	// Keep the server CA's CRL fresh so revocation checks during the TLS handshake aren't
+	// stuck with whatever was cached at startup.
+	go agt.Registrar().TrustStore().StartPeriodicRefresh(ctx)
 
-	// Wait for shutdown signal or error
-	select {
-	case <-sigChan:
-		fmt.Println("\nReceived shutdown signal, stopping...")
-		cancel()
-		// Wait for agent to stop
-		<-errChan
-
-	case err := <-errChan:
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Agent error: %v\n", err)
-			os.Exit(1)
-		}
+	// Start blocks until a clean shutdown (SIGINT/SIGTERM/SIGHUP, drained per shutdown.go's
+	// handleSignals) or a fatal error.
+	if err := agt.Start(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Agent error: %v\n", err)
+		os.Exit(1)
 	}
 
 	fmt.Println("Agent stopped")
 }
 
 func init() {
-	// No flags needed - everything is configured via environment variables
+	// Everything else is configured via environment variables; --log-format is the one flag
+	// worth a flag (operators flip it ad hoc when piping agent output into a terminal vs. a log
+	// aggregator), so it's bound into viper alongside AGENT_LOG_FORMAT rather than read directly.
+	rootCmd.PersistentFlags().String("log-format", "", "Log output format: text or json (overrides AGENT_LOG_FORMAT)")
+	viper.BindPFlag("log.format", rootCmd.PersistentFlags().Lookup("log-format"))
 }
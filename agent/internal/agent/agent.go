@@ -5,24 +5,40 @@ package agent
 
 import (
 	"bufio"
-	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/d-led/ex_gocd/agent/internal/backoff"
+	"github.com/d-led/ex_gocd/agent/internal/broker"
 	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/internal/console"
+	"github.com/d-led/ex_gocd/agent/internal/executor"
+	"github.com/d-led/ex_gocd/agent/internal/executor/plugin"
+	"github.com/d-led/ex_gocd/agent/internal/logging"
 	"github.com/d-led/ex_gocd/agent/internal/registration"
+	"github.com/d-led/ex_gocd/agent/internal/remoting"
+	grpctransport "github.com/d-led/ex_gocd/agent/internal/remoting/grpc"
+	"github.com/d-led/ex_gocd/agent/internal/remoting/transport"
+	"github.com/d-led/ex_gocd/agent/internal/secrets"
+	"github.com/d-led/ex_gocd/agent/internal/testresults"
 	"github.com/d-led/ex_gocd/agent/internal/websocket"
 	"github.com/d-led/ex_gocd/agent/pkg/protocol"
 	"github.com/google/uuid"
@@ -32,14 +48,35 @@ import (
 type Agent struct {
 	config    *config.Config
 	registrar *registration.Registrar
-	conn      *websocket.Connection
+	conn      transport.Transport
 	cookie    string
 	state     string
 
-	// Current build cancellation: guarded by buildMu
-	buildMu       sync.Mutex
-	currentBuild  string             // buildId of running build, or ""
-	cancelBuildFn context.CancelFunc  // call to cancel current build
+	// logger carries agent_uuid and remote_addr on every record; handleBuild adds build_id for
+	// the duration of a build and threads the result through context.Context into
+	// runBuildCommand/runOneCommand/streamReaderToConsole (see internal/logging).
+	logger *slog.Logger
+
+	// artifactStore is where "upload"/"download" BuildCommands persist and retrieve artifacts -
+	// see runOneCommand, config.Config.ArtifactStoreBackend, and executor.NewArtifactStore.
+	artifactStore executor.ArtifactStore
+
+	// httpClient is used for console log uploads and any other outbound HTTP calls handleBuild
+	// makes; overridable (e.g. in tests) to point at a server.Client() with a test TLS config.
+	httpClient *http.Client
+
+	// activeBuilds tracks every build currently running, keyed by buildId, so CancelBuildAction
+	// (websocket path) and a ping CANCEL/KILL_RUNNING_TASKS instruction (remoting path, see
+	// WorkerPool) can cancel the right job(s) even when WorkerPool is running several builds at
+	// once - a single currentBuild/cancelBuildFn pair (the pre-WorkerPool shape) could only ever
+	// track one. Guarded by buildMu.
+	buildMu      sync.Mutex
+	activeBuilds map[string]context.CancelFunc
+
+	// tlsConfig is read by runWithConnection on (re)connect; guarded by tlsMu so Reload can
+	// hot-swap it (e.g. after certificate renewal) without disturbing a build in progress.
+	tlsMu     sync.RWMutex
+	tlsConfig *tls.Config
 }
 
 // New creates a new Agent
@@ -50,12 +87,46 @@ func New(cfg *config.Config) (*Agent, error) {
 	}
 
 	return &Agent{
-		config:    cfg,
-		registrar: registration.New(cfg),
-		state:     "Idle",
+		config:       cfg,
+		registrar:    registration.New(cfg),
+		state:        "Idle",
+		logger:       logging.New(cfg.LogFormat).With("agent_uuid", cfg.UUID, "remote_addr", cfg.ServerURL.Host),
+		httpClient:   http.DefaultClient,
+		activeBuilds: make(map[string]context.CancelFunc),
+		artifactStore: executor.NewArtifactStore(cfg.ArtifactStoreBackend, cfg.ArtifactStoreDir,
+			cfg.S3Bucket, cfg.S3Region, cfg.S3Endpoint, cfg.S3AccessKey, cfg.S3SecretKey),
 	}, nil
 }
 
+// Registrar exposes the agent's Registrar so callers (e.g. cmd.runAgent) can wire up a
+// registration.Renewer for background certificate rotation.
+func (a *Agent) Registrar() *registration.Registrar {
+	return a.registrar
+}
+
+// Reload rebuilds the agent's TLS configuration from the current on-disk certificate and
+// private key. The new config takes effect on the next WebSocket (re)connect - it does not
+// tear down a connection that's already up, so a certificate renewal never drops a build in
+// progress.
+func (a *Agent) Reload() error {
+	tlsConfig, err := a.registrar.CreateTLSConfig()
+	if err != nil {
+		return err
+	}
+	a.tlsMu.Lock()
+	a.tlsConfig = tlsConfig
+	a.tlsMu.Unlock()
+	log.Println("Agent TLS configuration reloaded")
+	return nil
+}
+
+// currentTLSConfig returns the TLS config to use for the next WebSocket connection attempt.
+func (a *Agent) currentTLSConfig() *tls.Config {
+	a.tlsMu.RLock()
+	defer a.tlsMu.RUnlock()
+	return a.tlsConfig
+}
+
 // Start runs the agent lifecycle with automatic reconnection:
 // 1. Register with server
 // 2. Connect WebSocket (with reconnection)
@@ -68,22 +139,30 @@ func (a *Agent) Start(ctx context.Context) error {
 
 	// Register with server
 	log.Println("Registering with server...")
-	if err := a.registrar.Register(); err != nil {
+	if err := a.registrar.Register(ctx); err != nil {
 		return fmt.Errorf("registration failed: %w", err)
 	}
 	log.Println("Registration successful")
 
 	// Create TLS config for WebSocket
-	tlsConfig, err := a.registrar.CreateTLSConfig()
-	if err != nil {
+	if err := a.Reload(); err != nil {
 		return fmt.Errorf("failed to create TLS config: %w", err)
 	}
 
+	// Drain (SIGHUP) or fast-cancel (SIGINT/SIGTERM) an in-flight build before this context is
+	// canceled - see shutdown.go.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	a.handleSignals(ctx, cancel)
+
+	if a.config.UsesRemotingTransport() {
+		return a.runRemoting(ctx)
+	}
+
 	// Main reconnection loop
-	retryDelay := 2 * time.Second
-	maxRetryDelay := 60 * time.Second
+	reconnectBackoff := backoff.Strategy{Base: 2 * time.Second, Max: 60 * time.Second, Jitter: backoff.NoJitter}
 
-	for {
+	for attempt := 0; ; attempt++ {
 		select {
 		case <-ctx.Done():
 			log.Println("Agent shutting down...")
@@ -92,7 +171,7 @@ func (a *Agent) Start(ctx context.Context) error {
 		}
 
 		// Try to connect and run
-		err := a.runWithConnection(ctx, tlsConfig)
+		err := a.runWithConnection(ctx)
 		if err == nil {
 			return nil // Clean shutdown
 		}
@@ -104,16 +183,12 @@ func (a *Agent) Start(ctx context.Context) error {
 		}
 
 		// Log error and retry with backoff
+		retryDelay := reconnectBackoff.Next(attempt)
 		log.Printf("Connection lost: %v", err)
 		log.Printf("Reconnecting in %v...", retryDelay)
 
 		select {
 		case <-time.After(retryDelay):
-			// Exponential backoff with max
-			retryDelay = retryDelay * 2
-			if retryDelay > maxRetryDelay {
-				retryDelay = maxRetryDelay
-			}
 		case <-ctx.Done():
 			log.Println("Agent shutting down...")
 			return nil
@@ -121,17 +196,67 @@ func (a *Agent) Start(ctx context.Context) error {
 	}
 }
 
-// runWithConnection establishes WebSocket and runs until disconnection
-func (a *Agent) runWithConnection(ctx context.Context, tlsConfig *tls.Config) error {
-	// Connect WebSocket
+// runRemoting runs the agent against the classic, poll-based GoCD remoting API
+// (AGENT_TRANSPORT=remoting): one get_cookie, then get_work/ping polled through a
+// broker.RemotingBroker and WorkerPool, so up to cfg.MaxConcurrentJobs builds run at once
+// instead of the single build at a time the WebSocket/gRPC transport.Transport path handles.
+func (a *Agent) runRemoting(ctx context.Context) error {
+	client, err := remoting.NewClient(a.config, a.httpClient)
+	if err != nil {
+		return fmt.Errorf("remoting client: %w", err)
+	}
+	defer client.Close()
+
+	cookie, err := client.GetCookie(a.getRuntimeInfo())
+	if err != nil {
+		return fmt.Errorf("get_cookie: %w", err)
+	}
+	a.cookie = cookie
+
+	brk := broker.NewRemotingBroker(client, a.config.ServerURL.String(), a.config.WorkPollInterval, func(broker.Labels) *protocol.AgentRuntimeInfo {
+		return a.getRuntimeInfo()
+	})
+	filter := broker.Labels{Resources: a.config.ResourceList(), Environments: a.config.EnvironmentList()}
+	pool := NewWorkerPool(a, brk, brk.Send, filter, a.config.MaxConcurrentJobs)
+
+	go pool.PingLoop(ctx, func() (string, error) { return client.Ping(a.getRuntimeInfo()) }, a.config.HeartbeatInterval)
+	pool.Run(ctx)
+	return nil
+}
+
+// connect dials the transport selected by AGENT_TRANSPORT: "grpc" for the AgentStream RPC
+// (internal/remoting/grpc), anything else (including unset) for the default WebSocket JSON
+// protocol. Both share the same mTLS config, so a certificate renewal reloaded via a.Reload
+// applies regardless of which transport is active.
+func (a *Agent) connect(ctx context.Context) (transport.Transport, error) {
+	if a.config.UsesGRPCTransport() {
+		log.Println("Connecting to server via gRPC...")
+		conn, err := grpctransport.Connect(ctx, a.config, a.currentTLSConfig())
+		if err != nil {
+			return nil, fmt.Errorf("gRPC connection failed: %w", err)
+		}
+		log.Println("gRPC connected")
+		return conn, nil
+	}
+
 	log.Println("Connecting to server via WebSocket...")
-	conn, err := websocket.Connect(ctx, a.config, tlsConfig)
+	conn, err := websocket.Connect(ctx, a.config, a.currentTLSConfig())
 	if err != nil {
-		return fmt.Errorf("WebSocket connection failed: %w", err)
+		return nil, fmt.Errorf("WebSocket connection failed: %w", err)
+	}
+	log.Println("WebSocket connected")
+	return conn, nil
+}
+
+// runWithConnection establishes the configured transport (AGENT_TRANSPORT=ws|grpc) and runs
+// until disconnection.
+func (a *Agent) runWithConnection(ctx context.Context) error {
+	conn, err := a.connect(ctx)
+	if err != nil {
+		return err
 	}
 	defer conn.Close()
 	a.conn = conn
-	log.Println("WebSocket connected")
 
 	// Send join once so the server establishes the channel (do not send ping as join — that caused duplicate-join phx_close)
 	a.sendJoin()
@@ -163,10 +288,12 @@ func (a *Agent) runWithConnection(ctx context.Context, tlsConfig *tls.Config) er
 
 // handleMessage processes incoming messages from server
 func (a *Agent) handleMessage(msg *protocol.Message) error {
+	logger := a.logger.With("job_state", a.state)
+
 	switch msg.Action {
 	case "phx_reply":
 		// Phoenix channel reply to our ping (heartbeat ack). Connection is active.
-		log.Printf("Heartbeat acknowledged")
+		logger.Debug("heartbeat acknowledged")
 
 	case "presence_diff":
 		// Phoenix Presence broadcast (server tracks who is on the channel). No action needed.
@@ -180,43 +307,53 @@ func (a *Agent) handleMessage(msg *protocol.Message) error {
 		if len(preview) > 8 {
 			preview = preview[:8] + "..."
 		}
-		log.Printf("Server set agent cookie: %s", preview)
+		logger.Info("server set agent cookie", "cookie_preview", preview)
 
 	case protocol.ReregisterAction:
-		log.Println("Server requested re-registration")
+		logger.Info("server requested re-registration")
 		// Clean up and exit - supervisor will restart
 		return fmt.Errorf("re-registration requested")
 
 	case protocol.CancelBuildAction:
 		buildID := msg.BuildIdFromData()
+		logger = a.logger.With("build_id", buildID, "job_state", a.state)
 		a.buildMu.Lock()
-		cancelFn := a.cancelBuildFn
-		matches := a.currentBuild == buildID
+		cancelFn := a.activeBuilds[buildID]
 		a.buildMu.Unlock()
-		if matches && cancelFn != nil {
-			log.Printf("Cancelling build: %s", buildID)
+		if cancelFn != nil {
+			logger.Info("cancelling build")
 			cancelFn()
 		} else if buildID != "" {
-			log.Printf("Cancel requested for build %s (current build: %q)", buildID, a.currentBuild)
+			logger.Info("cancel requested for build not currently running")
 		}
 
 	case protocol.BuildAction:
+		if a.state == "Draining" {
+			logger.Info("draining: ignoring build assignment")
+			break
+		}
 		build := msg.DataBuild()
-		if build != nil {
-			log.Printf("Build assigned: %s (%s)", build.BuildId, build.BuildLocatorForDisplay)
-			a.handleBuild(build)
-		} else {
-			log.Printf("Build assigned but failed to parse payload")
+		if build == nil {
+			logger.Error("build assigned but failed to parse payload")
+			break
 		}
+		logger = a.logger.With("build_id", build.BuildId, "job_state", a.state)
+		if reason, ok := a.matchesBuild(build); !ok {
+			logger.Warn("rejecting build", "reason", reason)
+			a.conn.Send(protocol.RejectBuildMessage(build.BuildId, reason))
+			break
+		}
+		logger.Info("build assigned", "build_locator", build.BuildLocatorForDisplay)
+		a.handleBuild(build)
 
 	case "phx_close":
 		// Server closed the channel (e.g. duplicate join or intentional close); treat as normal close so we reconnect once
-		log.Println("Server closed channel (phx_close); will reconnect")
+		logger.Info("server closed channel (phx_close); will reconnect")
 		return fmt.Errorf("channel closed by server")
 
 	default:
 		// Unhandled action: likely a bug (new server message we don't support, or typo).
-		log.Printf("Unknown message action: %s", msg.Action)
+		logger.Warn("unknown message action", "action", msg.Action)
 	}
 
 	return nil
@@ -256,37 +393,94 @@ func (a *Agent) getRuntimeInfo() *protocol.AgentRuntimeInfo {
 		ElasticPluginId:              a.config.ElasticPluginID,
 		ElasticAgentId:               a.config.ElasticAgentID,
 		SupportsBuildCommandProtocol: true,
+		Resources:                    a.config.ResourceList(),
+		Environments:                 a.config.EnvironmentList(),
 	}
 }
 
-// handleBuild executes a build
+// matchesBuild reports whether the agent satisfies build's RequiredResources/Labels: every
+// RequiredResources entry must be in the agent's configured Resources (config.ResourceList,
+// which always includes an implicit "platform:GOOS/GOARCH"), and every Labels entry must be in
+// either Resources or Environments. Empty requirements always match - a Build with no
+// resources/labels set runs on any agent, same as GoCD server-side resource matching. On
+// mismatch it returns a human-readable reason for the RejectBuildMessage reply.
+func (a *Agent) matchesBuild(build *protocol.Build) (reason string, ok bool) {
+	resources := a.config.ResourceList()
+	environments := a.config.EnvironmentList()
+
+	for _, r := range build.RequiredResources {
+		if !containsString(resources, r) {
+			return fmt.Sprintf("missing required resource %q", r), false
+		}
+	}
+	for _, l := range build.Labels {
+		if !containsString(resources, l) && !containsString(environments, l) {
+			return fmt.Sprintf("missing label %q", l), false
+		}
+	}
+	return "", true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// handleBuild executes a build received over the WebSocket path, reporting status via the
+// connection's own Send and with no broker to Ack/Nack - see handleBuildWithSend.
 func (a *Agent) handleBuild(build *protocol.Build) {
+	a.handleBuildWithSend(build, a.conn.Send, nil)
+}
+
+// handleBuildWithSend executes build, reporting status via send rather than a.conn.Send
+// directly, so it works equally for the WebSocket path (send wraps a.conn.Send, brk nil) and the
+// remoting/WorkerPool path (send posts report_* calls some other way, brk is the Broker the
+// build was Pulled from - Acked on completion, regardless of result, since a finished build -
+// passed, failed, or cancelled - is no longer in flight).
+func (a *Agent) handleBuildWithSend(build *protocol.Build, send func(*protocol.Message), brk broker.Broker) {
+	a.buildMu.Lock()
 	a.state = "Building"
-	defer func() { a.state = "Idle" }()
+	a.buildMu.Unlock()
+	defer func() {
+		// Don't clobber "Draining" - handleSignals is waiting for activeBuilds to drain, not
+		// for state to go back to "Idle", and a drained agent should stay refusing new builds.
+		a.buildMu.Lock()
+		if a.state != "Draining" && len(a.activeBuilds) == 0 {
+			a.state = "Idle"
+		}
+		a.buildMu.Unlock()
+	}()
 
+	logger := a.logger.With("build_id", build.BuildId)
 	ctx, cancel := context.WithCancel(context.Background())
+	ctx = logging.WithLogger(ctx, logger)
 	a.buildMu.Lock()
-	a.currentBuild = build.BuildId
-	a.cancelBuildFn = cancel
+	a.activeBuilds[build.BuildId] = cancel
 	a.buildMu.Unlock()
 	defer func() {
 		a.buildMu.Lock()
-		a.currentBuild = ""
-		a.cancelBuildFn = nil
+		delete(a.activeBuilds, build.BuildId)
 		a.buildMu.Unlock()
+		if brk != nil {
+			brk.Ack(build.BuildId)
+		}
 	}()
 
-	log.Printf("Executing build: %s", build.BuildId)
-	a.reportStatus(build.BuildId, "Building", "")
+	logger.Info("executing build", "job_state", "Building")
+	a.reportStatusTo(send, build.BuildId, "Building", "", nil)
 
 	result := "Passed"
 	if build.BuildCommand != nil && build.BuildCommand.Command != "" {
 		if err := a.runBuildCommand(ctx, build); err != nil {
 			if err == context.Canceled {
 				result = "Cancelled"
-				log.Printf("Build %s was cancelled", build.BuildId)
+				logger.Info("build cancelled", "job_state", result)
 			} else {
-				log.Printf("Build command failed: %v", err)
+				logger.Error("build command failed", "job_state", "Failed", "error", err)
 				result = "Failed"
 			}
 		}
@@ -299,36 +493,144 @@ func (a *Agent) handleBuild(build *protocol.Build) {
 		}
 	}
 
-	a.reportStatus(build.BuildId, "Completing", result)
-	a.reportStatus(build.BuildId, "Completed", result)
+	testResults := a.collectTestResults(ctx, build, logger)
+
+	a.reportStatusTo(send, build.BuildId, "Completing", result, nil)
+	a.reportStatusTo(send, build.BuildId, "Completed", result, testResults)
+	logger.Info("build finished", "job_state", result)
+}
+
+// collectTestResults scans build.ArtifactPlans for "unit" entries once the build's command has
+// finished, parsing every matched file with internal/testresults and uploading it via
+// a.artifactStore (the same store runUpload uses) under plan.Dest plus its path relative to the
+// working dir, so the raw XML is available alongside the aggregate summary sent with the
+// Completed report. A plan matching nothing is skipped, and an upload failure is logged and
+// skipped, rather than failing the already-finished build.
+func (a *Agent) collectTestResults(ctx context.Context, build *protocol.Build, logger *slog.Logger) *protocol.TestResultsSummary {
+	dir := a.config.WorkingDir
+	var total testresults.Summary
+	var any bool
+
+	for _, plan := range build.ArtifactPlans {
+		if plan.Type != protocol.ArtifactPlanTypeUnit || plan.Src == "" {
+			continue
+		}
+		summary, files, err := testresults.Collect(dir, []string{plan.Src})
+		if err != nil {
+			logger.Warn("test result collection failed", "src", plan.Src, "error", err)
+			continue
+		}
+		for _, path := range files {
+			rel, relErr := filepath.Rel(dir, path)
+			if relErr != nil {
+				rel = filepath.Base(path)
+			}
+			key := filepath.ToSlash(filepath.Join(plan.Dest, rel))
+			if err := a.runArtifactOp(ctx, func() error { return a.artifactStore.Put(key, path, io.Discard) }); err != nil {
+				logger.Warn("test report upload failed", "file", path, "error", err)
+			}
+		}
+		if len(files) > 0 {
+			any = true
+			total.Add(summary)
+		}
+	}
+	if !any {
+		return nil
+	}
+
+	logger.Info("test results collected", "total", total.Total, "failed", total.Failed, "skipped", total.Skipped)
+	return &protocol.TestResultsSummary{
+		Total:      total.Total,
+		Failed:     total.Failed,
+		Skipped:    total.Skipped,
+		DurationMs: total.Duration.Milliseconds(),
+	}
 }
 
 // runBuildCommand runs the build's command (or subCommands in sequence) in the agent working dir.
 // When build.ConsoleUrl is set, stdout/stderr are captured and streamed to that URL with timestamp prefix.
-// ctx can be cancelled to abort the build (e.g. cancelBuild from server).
+// build.Secrets are resolved once up front (see resolveBuildSecrets) and scoped into each
+// command's environment and console output by runOneCommand; a gocd-agent-plugin-<name>
+// executor discovered under config.PluginDirOrDefault is likewise available to every
+// sub-command, not just the top-level one. ctx can be cancelled to abort the build (e.g.
+// cancelBuild from server).
 func (a *Agent) runBuildCommand(ctx context.Context, build *protocol.Build) error {
 	cmd := build.BuildCommand
 	if cmd == nil {
 		return nil
 	}
+	logger := logging.FromContext(ctx)
+	buildSecrets := a.resolveBuildSecrets(build, logger)
+	plugins := a.discoverPluginExecutors(logger)
+
 	if len(cmd.SubCommands) > 0 {
 		for _, sub := range cmd.SubCommands {
-			if err := a.runOneCommand(ctx, build, sub); err != nil {
+			// A cancellation that lands between two sub-commands (e.g. the previous one just
+			// finished on its own as CancelBuildAction arrives) must stop the sequence here
+			// rather than starting the next sub-command and relying on it to notice ctx.Done().
+			if ctx.Err() != nil {
+				return context.Canceled
+			}
+			if err := a.runOneCommand(ctx, build, sub, buildSecrets, plugins); err != nil {
 				return err
 			}
 		}
 		return nil
 	}
-	return a.runOneCommand(ctx, build, cmd)
+	return a.runOneCommand(ctx, build, cmd, buildSecrets, plugins)
 }
 
-// runOneCommand runs a single BuildCommand (command + args), streaming output to build.ConsoleUrl when set.
-// ctx can be cancelled to kill the process (returns context.Canceled).
-func (a *Agent) runOneCommand(ctx context.Context, build *protocol.Build, cmd *protocol.BuildCommand) error {
-	path := cmd.Command
-	if path == "" {
+// resolveBuildSecrets resolves build.Secrets via secrets.Resolve, using the locator's scheme
+// prefix ("env:"/"file:"/"vault:") to pick a Provider and falling back to EnvProvider for a
+// locator with none (the common case - a bare env var name). A resolution failure is logged and
+// the rest proceed, matching plugin discovery's own "skip what's broken" behavior below - a
+// build shouldn't fail outright just because one secret's provider is unreachable.
+func (a *Agent) resolveBuildSecrets(build *protocol.Build, logger *slog.Logger) []secrets.Secret {
+	if len(build.Secrets) == 0 {
 		return nil
 	}
+	providers := map[string]secrets.Provider{
+		"env":   secrets.EnvProvider{},
+		"file":  secrets.FileProvider{},
+		"vault": secrets.VaultProvider{},
+	}
+	resolved, err := secrets.Resolve(build.Secrets, providers, secrets.EnvProvider{})
+	if err != nil {
+		logger.Warn("secret resolution failed", "error", err)
+	}
+	return resolved
+}
+
+// discoverPluginExecutors finds gocd-agent-plugin-<name> binaries under
+// config.PluginDirOrDefault (see executor/plugin) and returns the BuildCommand names they
+// advertise as executor.Executors, so runOneCommand can dispatch a custom cmd.Name to one
+// instead of silently no-oping it.
+func (a *Agent) discoverPluginExecutors(logger *slog.Logger) map[string]executor.Executor {
+	dir := a.config.PluginDirOrDefault()
+	discovered, err := plugin.Discover(dir)
+	if err != nil {
+		logger.Warn("plugin discovery failed", "dir", dir, "error", err)
+	}
+	if len(discovered) == 0 {
+		return nil
+	}
+	executors := make(map[string]executor.Executor)
+	plugin.Register(executors, discovered)
+	return executors
+}
+
+// runOneCommand runs a single BuildCommand, streaming output to build.ConsoleUrl when set. A
+// "command" (command + args) runs as a plain OS process, or inside a Docker container when its
+// "backend" attribute is "docker" (or, absent that, AGENT_EXECUTOR_BACKEND is) - see
+// runDockerCommand. An "upload"/"download" cmd.Name instead moves files to/from a.artifactStore -
+// see runUpload/runDownload - a "fetch" cmd.Name downloads cmd.URL directly, see runFetch, and
+// any other cmd.Name matching a discovered plugin executor runs through that instead, see
+// runPluginCommand. buildSecrets, once resolved, are masked in console output and scoped into
+// a command's environment (honoring its "allowSecrets" attribute) wherever it actually runs -
+// runLocalCommand, runDockerCommand, and runPluginCommand all apply the same rule. ctx can be
+// cancelled to kill the process (returns context.Canceled).
+func (a *Agent) runOneCommand(ctx context.Context, build *protocol.Build, cmd *protocol.BuildCommand, buildSecrets []secrets.Secret, plugins map[string]executor.Executor) error {
 	dir := a.config.WorkingDir
 	if cmd.WorkingDir != "" {
 		dir = cmd.WorkingDir
@@ -338,92 +640,523 @@ func (a *Agent) runOneCommand(ctx context.Context, build *protocol.Build, cmd *p
 		return fmt.Errorf("working dir: %w", err)
 	}
 
-	c := exec.CommandContext(ctx, path, cmd.Args...)
-	c.Dir = absDir
+	logger := logging.FromContext(ctx)
 
+	var writer *console.Writer
 	if build.ConsoleUrl != "" {
-		stdoutPipe, _ := c.StdoutPipe()
-		stderrPipe, _ := c.StderrPipe()
-		c.Stdin = nil
+		w, werr := a.newConsoleWriter(absDir, build)
+		if werr != nil {
+			logger.Warn("console writer unavailable; console output will go to agent stdout/stderr instead", "error", werr)
+		} else {
+			writer = w
+			defer func() {
+				// Flush guarantees the buffered batch is POSTed (with retries) before handleBuild
+				// reports "Completed"; Close stops the flush loop once that's done.
+				writer.Flush()
+				writer.Close()
+			}()
+		}
+	}
+
+	switch cmd.Name {
+	case protocol.CommandUpload:
+		return a.runUpload(ctx, writer, absDir, cmd)
+	case protocol.CommandDownload:
+		return a.runDownload(ctx, writer, absDir, cmd)
+	case protocol.CommandFetch:
+		return a.runFetch(ctx, writer, absDir, cmd)
+	}
+
+	if execFn, ok := plugins[cmd.Name]; ok {
+		return a.runPluginCommand(ctx, writer, absDir, cmd, execFn, buildSecrets)
+	}
+
+	if cmd.Command == "" {
+		return nil
+	}
+
+	if backendName(a.config, cmd) == "docker" {
+		return a.runDockerCommand(ctx, writer, absDir, cmd, buildSecrets)
+	}
+	return a.runLocalCommand(ctx, writer, absDir, cmd, buildSecrets)
+}
+
+// newConsoleWriter opens a durable console.Writer backed by build.BuildLocator's logstream ring
+// when a locator is available, so a transient network hiccup (or an agent restart mid-build)
+// doesn't lose buffered console output; it falls back to a plain, non-durable console.NewWriter
+// otherwise.
+func (a *Agent) newConsoleWriter(absDir string, build *protocol.Build) (*console.Writer, error) {
+	if build.BuildLocator == "" {
+		return console.NewWriter(a.httpClient, a.config.ServerURL, build.ConsoleUrl, console.WriterMaxBufferBytesFromEnv())
+	}
+	return console.NewDurableWriter(a.httpClient, a.config.ServerURL, build.ConsoleUrl, absDir, build.BuildLocator, console.WriterMaxBufferBytesFromEnv())
+}
+
+// runUpload runs an "upload" BuildCommand: every file matching cmd.Src (a glob pattern relative
+// to absDir) is stored under cmd.Dest plus its path relative to absDir, via a.artifactStore.
+func (a *Agent) runUpload(ctx context.Context, writer *console.Writer, absDir string, cmd *protocol.BuildCommand) error {
+	if cmd.Src == "" {
+		return fmt.Errorf("upload: src is required")
+	}
+	matches, err := filepath.Glob(filepath.Join(absDir, cmd.Src))
+	if err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("upload: no files matched %s", cmd.Src)
+	}
+
+	out := consoleOrStdout(writer)
+	for _, match := range matches {
+		rel, relErr := filepath.Rel(absDir, match)
+		if relErr != nil {
+			rel = filepath.Base(match)
+		}
+		key := filepath.ToSlash(filepath.Join(cmd.Dest, rel))
+		if err := a.runArtifactOp(ctx, func() error { return a.artifactStore.Put(key, match, out) }); err != nil {
+			return fmt.Errorf("upload %s: %w", match, err)
+		}
+	}
+	return nil
+}
+
+// runDownload runs a "download" BuildCommand: fetches cmd.Src from a.artifactStore to cmd.Dest
+// (relative to absDir; defaults to cmd.Src's base name).
+func (a *Agent) runDownload(ctx context.Context, writer *console.Writer, absDir string, cmd *protocol.BuildCommand) error {
+	if cmd.Src == "" {
+		return fmt.Errorf("download: src is required")
+	}
+	dest := cmd.Dest
+	if dest == "" {
+		dest = filepath.Base(cmd.Src)
+	}
+	destPath := filepath.Join(absDir, dest)
+
+	out := consoleOrStdout(writer)
+	if err := a.runArtifactOp(ctx, func() error { return a.artifactStore.Get(cmd.Src, destPath, out) }); err != nil {
+		return fmt.Errorf("download %s: %w", cmd.Src, err)
+	}
+	return nil
+}
+
+// runFetch runs a "fetch" BuildCommand: GETs cmd.URL directly (unlike download, which reads from
+// a.artifactStore) to cmd.Dest (relative to absDir; defaults to cmd.URL's base name), verifying
+// the result against cmd.Checksum ("algo:hex") when set.
+func (a *Agent) runFetch(ctx context.Context, writer *console.Writer, absDir string, cmd *protocol.BuildCommand) error {
+	if cmd.URL == "" {
+		return fmt.Errorf("fetch: url is required")
+	}
+	dest := cmd.Dest
+	if dest == "" {
+		dest = filepath.Base(cmd.URL)
+	}
+	destPath := filepath.Join(absDir, dest)
+
+	out := consoleOrStdout(writer)
+	if err := a.runArtifactOp(ctx, func() error { return fetchURLToFile(cmd.URL, destPath, cmd.Checksum, out) }); err != nil {
+		return fmt.Errorf("fetch %s: %w", cmd.URL, err)
+	}
+	return nil
+}
+
+// fetchURLToFile downloads url to destPath, verifying it against checksum ("algo:hex") when set.
+func fetchURLToFile(url, destPath, checksum string, progress io.Writer) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	h, algo, err := checksumHasher(checksum)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = out
+	if h != nil {
+		w = io.MultiWriter(out, h)
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "fetched %s (%d bytes)\n", url, n)
+
+	if h == nil {
+		return nil
+	}
+	want := strings.SplitN(checksum, ":", 2)[1]
+	got := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(want, got) {
+		return fmt.Errorf("checksum mismatch: want %s:%s, got %s:%s", algo, want, algo, got)
+	}
+	return nil
+}
+
+// checksumHasher parses an "algo:hex" checksum string into a hash.Hash, or (nil, "", nil) if
+// checksum is empty (no verification requested).
+func checksumHasher(checksum string) (hash.Hash, string, error) {
+	if checksum == "" {
+		return nil, "", nil
+	}
+	parts := strings.SplitN(checksum, ":", 2)
+	if len(parts) != 2 {
+		return nil, "", fmt.Errorf("checksum: expected \"algo:hex\", got %q", checksum)
+	}
+	algo := parts[0]
+	switch algo {
+	case "sha256":
+		return sha256.New(), algo, nil
+	case "md5":
+		return md5.New(), algo, nil
+	default:
+		return nil, "", fmt.Errorf("checksum: unsupported algorithm %q", algo)
+	}
+}
+
+// runArtifactOp runs fn in a goroutine and returns its result, or context.Canceled if ctx is
+// cancelled first - mirroring waitWithCancellation's ctx-driven model, except there's no process
+// to terminate here: a cancelled fn keeps running in the background and its eventual result is
+// discarded rather than leaving the caller blocked on a slow upload/download.
+func (a *Agent) runArtifactOp(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return context.Canceled
+	}
+}
+
+// consoleOrStdout returns writer as an io.Writer, or os.Stdout if writer is nil (no ConsoleUrl,
+// mirroring runDockerCommand/dockerPull's fallback).
+func consoleOrStdout(writer *console.Writer) io.Writer {
+	if writer != nil {
+		return writer
+	}
+	return os.Stdout
+}
+
+// writerOnly hides an io.Writer's Close method (if any) behind a plain io.Writer, so wrapping a
+// shared writer in a secrets.RedactingWriter never lets that RedactingWriter's own Close close
+// the shared writer out from under its actual owner - e.g. runOneCommand's deferred
+// writer.Flush()/writer.Close() on its *console.Writer.
+type writerOnly struct{ io.Writer }
+
+// maskedConsole wraps out in a secrets.RedactingWriter masking values, or returns out unchanged
+// if values is empty - a build with no secrets shouldn't pay for a no-op wrapper.
+func maskedConsole(out io.Writer, values []string) io.Writer {
+	if len(values) == 0 {
+		return out
+	}
+	return secrets.NewRedactingWriter(writerOnly{out}, values)
+}
+
+// closeIfMasked flushes out's held-back bytes if maskedConsole wrapped it in a
+// secrets.RedactingWriter, so a secret split across the last couple of Write calls still makes
+// it to the console instead of being silently dropped.
+func closeIfMasked(out io.Writer) {
+	if masked, ok := out.(*secrets.RedactingWriter); ok {
+		masked.Close()
+	}
+}
+
+// backendName picks the backend for cmd: its "backend" attribute if set, else the agent's
+// configured default (AGENT_EXECUTOR_BACKEND) - mirroring executor.ResolveBackend.
+func backendName(cfg *config.Config, cmd *protocol.BuildCommand) string {
+	if name, ok := cmd.Attributes["backend"].(string); ok && name != "" {
+		return name
+	}
+	return cfg.ExecutorBackend
+}
+
+// runLocalCommand runs cmd.Command as a plain OS process, streaming stdout/stderr to writer
+// (prefixed per line) when set, or to the agent's own stdout/stderr otherwise. The process runs
+// as the leader of its own process group (setProcessGroup) so cancellation via ctx can reach
+// children a shell spawns (make, docker, ...), not just the leader - see waitWithCancellation.
+// buildSecrets scoped to cmd (see secrets.EnvForCommand) are added to the process's environment,
+// and masked in whatever it writes to writer (see maskedConsole).
+func (a *Agent) runLocalCommand(ctx context.Context, writer *console.Writer, absDir string, cmd *protocol.BuildCommand, buildSecrets []secrets.Secret) error {
+	c := exec.Command(cmd.Command, cmd.Args...)
+	c.Dir = absDir
+	setProcessGroup(c)
+	if secretEnv := secrets.EnvForCommand(buildSecrets, cmd.Name, allowSecretsAttr(cmd)); len(secretEnv) > 0 {
+		c.Env = append(os.Environ(), secretEnv...)
+	}
+
+	if writer == nil {
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
 		if err := c.Start(); err != nil {
 			return err
 		}
-		var wg sync.WaitGroup
-		streamToConsole := func(prefix string, r io.Reader) {
-			defer wg.Done()
-			a.streamReaderToConsole(build.ConsoleUrl, prefix, r)
-		}
-		wg.Add(2)
-		go streamToConsole("", stdoutPipe)
-		go streamToConsole("stderr: ", stderrPipe)
+		return a.waitWithCancellation(ctx, c, waitAsync(c))
+	}
+
+	stdoutPipe, _ := c.StdoutPipe()
+	stderrPipe, _ := c.StderrPipe()
+	c.Stdin = nil
+	if err := c.Start(); err != nil {
+		return err
+	}
+	out := maskedConsole(writer, secrets.Values(buildSecrets))
+	var wg sync.WaitGroup
+	streamToConsole := func(prefix string, r io.Reader) {
+		defer wg.Done()
+		a.streamReaderToConsole(ctx, out, prefix, r)
+	}
+	wg.Add(2)
+	go streamToConsole("", stdoutPipe)
+	go streamToConsole("stderr: ", stderrPipe)
+
+	done := make(chan error, 1)
+	go func() {
 		wg.Wait()
-		err := c.Wait()
-		if err != nil && ctx.Err() == context.Canceled {
-			return context.Canceled
-		}
+		closeIfMasked(out)
+		done <- c.Wait()
+	}()
+	return a.waitWithCancellation(ctx, c, done)
+}
+
+// waitAsync runs c.Wait() in a goroutine and returns a channel that receives its result exactly
+// once, so callers can select on it alongside ctx.Done().
+func waitAsync(c *exec.Cmd) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	return done
+}
+
+// waitWithCancellation waits for c to exit via done (which must deliver c.Wait()'s result
+// exactly once), or for ctx to be cancelled. On cancellation it sends SIGTERM to c's whole
+// process group, waits up to config.KillGrace for a clean exit, then escalates to SIGKILL -
+// giving a build's own cleanup (trap handlers, docker --rm, ...) a chance to run before the
+// agent forces the issue.
+func (a *Agent) waitWithCancellation(ctx context.Context, c *exec.Cmd, done <-chan error) error {
+	select {
+	case err := <-done:
 		return err
+	case <-ctx.Done():
 	}
 
-	c.Stdout = os.Stdout
-	c.Stderr = os.Stderr
-	err = c.Run()
+	logger := logging.FromContext(ctx)
+	if err := terminateProcessGroup(c); err != nil {
+		logger.Warn("SIGTERM to process group failed", "error", err)
+	}
+
+	grace := a.config.KillGrace
+	if grace <= 0 {
+		grace = 10 * time.Second
+	}
+
+	select {
+	case <-done:
+		return context.Canceled
+	case <-time.After(grace):
+	}
+
+	logger.Warn("process did not exit within kill grace; sending SIGKILL", "kill_grace", grace)
+	if err := killProcessGroup(c); err != nil {
+		logger.Warn("SIGKILL to process group failed", "error", err)
+	}
+	<-done // drain the exit status from the kill; context.Canceled already tells the caller why
+	return context.Canceled
+}
+
+// runDockerCommand runs cmd.Command+Args inside a container, configured via cmd.Attributes -
+// image (required), pull, entrypoint, volumes ([]interface{} of "host:container[:mode]"),
+// networks, privileged - the same attribute contract as executor.Docker, for pipelines that
+// declare containerized steps directly on a BuildCommand rather than through a BuildSession.
+// absDir is mounted into the container at the same path and used as its working dir. Combined
+// stdout/stderr go to out (writer, or the agent's own stdout if out is nil) - unlike
+// runLocalCommand, output isn't split into timestamped lines, matching executor.Docker's own
+// Session.Console() streaming. buildSecrets scoped to cmd are passed into the container with
+// "-e" (a "docker run" process doesn't inherit the agent's own c.Env the way a local command
+// does) and masked in out the same way runLocalCommand masks writer.
+func (a *Agent) runDockerCommand(ctx context.Context, out *console.Writer, absDir string, cmd *protocol.BuildCommand, buildSecrets []secrets.Secret) error {
+	image, _ := cmd.Attributes["image"].(string)
+	if image == "" {
+		return fmt.Errorf("docker backend: attributes.image is required")
+	}
+
+	if dockerAttrBool(cmd.Attributes["pull"]) {
+		if err := a.dockerPull(ctx, image, out); err != nil {
+			return err
+		}
+	}
+
+	dockerArgs := []string{"run", "--rm", "-w", absDir, "-v", absDir + ":" + absDir}
+	for _, v := range dockerAttrStrings(cmd.Attributes["volumes"]) {
+		dockerArgs = append(dockerArgs, "-v", v)
+	}
+	for _, n := range dockerAttrStrings(cmd.Attributes["networks"]) {
+		dockerArgs = append(dockerArgs, "--network", n)
+	}
+	if dockerAttrBool(cmd.Attributes["privileged"]) {
+		dockerArgs = append(dockerArgs, "--privileged")
+	}
+	if entrypoint, ok := cmd.Attributes["entrypoint"].(string); ok && entrypoint != "" {
+		dockerArgs = append(dockerArgs, "--entrypoint", entrypoint)
+	}
+	for _, kv := range secrets.EnvForCommand(buildSecrets, cmd.Name, allowSecretsAttr(cmd)) {
+		dockerArgs = append(dockerArgs, "-e", kv)
+	}
+	dockerArgs = append(dockerArgs, image, cmd.Command)
+	dockerArgs = append(dockerArgs, cmd.Args...)
+
+	c := exec.CommandContext(ctx, "docker", dockerArgs...)
+	var stdio io.Writer = os.Stdout
+	if out != nil {
+		stdio = out
+	}
+	stdio = maskedConsole(stdio, secrets.Values(buildSecrets))
+	c.Stdout, c.Stderr = stdio, stdio
+	err := c.Run()
+	closeIfMasked(stdio)
 	if err != nil && ctx.Err() == context.Canceled {
 		return context.Canceled
 	}
 	return err
 }
 
-// streamReaderToConsole reads lines from r, prefixes each with "HH:mm:ss.SSS [prefix]", and POSTs to consoleURL.
-func (a *Agent) streamReaderToConsole(consoleURL, linePrefix string, r io.Reader) {
-	scanner := bufio.NewScanner(r)
-	scanner.Buffer(nil, 64*1024)
-	for scanner.Scan() {
-		line := scanner.Text()
-		ts := time.Now().Format("15:04:05.000")
-		payload := ts + " " + linePrefix + line + "\n"
-		if err := postConsole(consoleURL, payload); err != nil {
-			log.Printf("Console POST failed: %v", err)
+// runPluginCommand runs cmd through execFn, a gocd-agent-plugin-<name> executor discovered by
+// discoverPluginExecutors, giving it the same working dir, scoped secret env, and masked console
+// as runLocalCommand/runDockerCommand would - see executor/plugin and executor.Session.
+func (a *Agent) runPluginCommand(ctx context.Context, writer *console.Writer, absDir string, cmd *protocol.BuildCommand, execFn executor.Executor, buildSecrets []secrets.Secret) error {
+	out := maskedConsole(consoleOrStdout(writer), secrets.Values(buildSecrets))
+	defer closeIfMasked(out)
+	env := append(envFromDir(absDir), secrets.EnvForCommand(buildSecrets, cmd.Name, allowSecretsAttr(cmd))...)
+	return execFn(&pluginSession{ctx: ctx, dir: absDir, console: out, env: env}, cmd)
+}
+
+// pluginSession adapts runPluginCommand's local state to executor.Session, the interface a
+// gocd-agent-plugin-<name> executor expects - the same role BuildSession plays for the
+// executor.Registry()-based path, just without compose/backend/artifact-store support, which no
+// plugin command in this path needs.
+type pluginSession struct {
+	ctx     context.Context
+	dir     string
+	console io.Writer
+	env     []string
+}
+
+func (s *pluginSession) WorkingDir() string { return s.dir }
+func (s *pluginSession) Console() io.Writer { return s.console }
+func (s *pluginSession) Env() []string      { return s.env }
+func (s *pluginSession) Canceled() bool     { return s.ctx.Err() != nil }
+
+// dockerPull pulls image, logging in to its registry first when AGENT_DOCKER_REGISTRY_USERNAME
+// is set (AGENT_DOCKER_REGISTRY_PASSWORD supplies the password via stdin, never as an argument).
+func (a *Agent) dockerPull(ctx context.Context, image string, out *console.Writer) error {
+	if a.config.DockerRegistryUsername != "" {
+		login := exec.CommandContext(ctx, "docker", "login", registryHost(image), "--username", a.config.DockerRegistryUsername, "--password-stdin")
+		login.Stdin = strings.NewReader(a.config.DockerRegistryPassword)
+		if out != nil {
+			login.Stdout, login.Stderr = out, out
+		} else {
+			login.Stdout, login.Stderr = os.Stdout, os.Stderr
+		}
+		if err := login.Run(); err != nil {
+			return fmt.Errorf("docker login: %w", err)
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		payload := time.Now().Format("15:04:05.000") + " [scanner error] " + err.Error() + "\n"
-		_ = postConsole(consoleURL, payload)
+
+	pull := exec.CommandContext(ctx, "docker", "pull", image)
+	if out != nil {
+		pull.Stdout, pull.Stderr = out, out
+	} else {
+		pull.Stdout, pull.Stderr = os.Stdout, os.Stderr
 	}
+	if err := pull.Run(); err != nil {
+		return fmt.Errorf("docker pull %s: %w", image, err)
+	}
+	return nil
 }
 
-// postConsole POSTs body as text/plain to the given URL.
-func postConsole(consoleURL, body string) error {
-	if consoleURL == "" {
-		return nil
+// registryHost extracts the registry host from image's first path segment (e.g.
+// "registry.example.com/team/app" -> "registry.example.com"), or "" for Docker Hub, where
+// `docker login` expects no server argument.
+func registryHost(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) != 2 {
+		return ""
 	}
-	req, err := http.NewRequest(http.MethodPost, consoleURL, strings.NewReader(body))
-	if err != nil {
-		return err
+	first := parts[0]
+	if strings.Contains(first, ".") || strings.Contains(first, ":") || first == "localhost" {
+		return first
 	}
-	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return err
+	return ""
+}
+
+func dockerAttrBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		parsed, _ := strconv.ParseBool(b)
+		return parsed
+	default:
+		return false
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("console POST %s: %s", resp.Status, bytes.TrimSpace(mustRead(resp.Body)))
+}
+
+func dockerAttrStrings(v interface{}) []string {
+	switch vs := v.(type) {
+	case []string:
+		return vs
+	case []interface{}:
+		out := make([]string, 0, len(vs))
+		for _, item := range vs {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
 	}
-	return nil
 }
 
-func mustRead(r io.Reader) []byte {
-	b, _ := io.ReadAll(r)
-	return b
+// streamReaderToConsole reads lines from r and writes each, prefixed with linePrefix, to w. w is
+// expected to be a *console.Writer (or equivalent), which adds its own timestamp prefix and
+// handles batching/retries - this just does the line framing. Write failures are logged via the
+// build_id-scoped logger threaded through ctx by handleBuild.
+func (a *Agent) streamReaderToConsole(ctx context.Context, w io.Writer, linePrefix string, r io.Reader) {
+	logger := logging.FromContext(ctx)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024)
+	for scanner.Scan() {
+		if _, err := w.Write([]byte(linePrefix + scanner.Text())); err != nil {
+			logger.Warn("console write failed", "error", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		_, _ = w.Write([]byte("[scanner error] " + err.Error()))
+	}
 }
 
-// reportStatus reports job status to server
-func (a *Agent) reportStatus(buildID, jobState, result string) {
+// reportStatusTo builds a report message for jobState/result and hands it to send - a.conn.Send
+// on the WebSocket path, or whatever the remoting/WorkerPool path uses to surface it. testResults
+// is only meaningful (and only ever non-nil) on the "Completed" report - see
+// collectTestResults' testresults.Collect call.
+func (a *Agent) reportStatusTo(send func(*protocol.Message), buildID, jobState, result string, testResults *protocol.TestResultsSummary) {
 	report := &protocol.Report{
 		BuildId:          buildID,
 		JobState:         jobState,
 		Result:           result,
 		AgentRuntimeInfo: a.getRuntimeInfo(),
+		TestResults:      testResults,
 	}
 
 	var msg *protocol.Message
@@ -436,7 +1169,7 @@ func (a *Agent) reportStatus(buildID, jobState, result string) {
 		msg = protocol.ReportCurrentStatusMessage(report)
 	}
 
-	a.conn.Send(msg)
+	send(msg)
 }
 
 // loadOrGenerateUUID loads existing UUID or generates a new one
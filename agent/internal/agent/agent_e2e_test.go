@@ -5,14 +5,18 @@
 package agent
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/d-led/ex_gocd/agent/internal/broker"
 	"github.com/d-led/ex_gocd/agent/internal/config"
 	"github.com/d-led/ex_gocd/agent/internal/remoting"
 	"github.com/d-led/ex_gocd/agent/pkg/protocol"
@@ -57,7 +61,7 @@ func TestExecuteJob_Success(t *testing.T) {
 	build := &protocol.Build{
 		BuildId:      "pipeline/1/stage/1/job1",
 		BuildLocator: "pipeline/1/stage/1/job1",
-		ConsoleUrl:  server.URL + "/remoting/files/p/1/s/1/job1/cruise-output/console.log",
+		ConsoleUrl:   server.URL + "/remoting/files/p/1/s/1/job1/cruise-output/console.log",
 		BuildCommand: &protocol.BuildCommand{
 			Name: protocol.CommandCompose,
 			SubCommands: []*protocol.BuildCommand{
@@ -111,15 +115,16 @@ const buildWorkJSON = `{
 	}
 }`
 
-// TestExecuteJob_RemotingPath runs get_cookie -> get_work (mock returns one job) -> execute -> assert Passed.
+// TestExecuteJob_RemotingPath runs get_cookie -> broker.RemotingBroker.Pull (mock returns one
+// job, then NoWork) -> agent.WorkerPool -> execute -> assert Passed, the same path
+// Agent.runRemoting drives in production.
 func TestExecuteJob_RemotingPath(t *testing.T) {
 	dir := t.TempDir()
 	configDir := filepath.Join(dir, "config")
 	require.NoError(t, os.MkdirAll(configDir, 0755))
 	require.NoError(t, os.WriteFile(filepath.Join(configDir, "token"), []byte("test-token"), 0644))
 
-	var completedResult string
-	var mu sync.Mutex
+	var getWorkCalls int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			w.WriteHeader(http.StatusMethodNotAllowed)
@@ -131,7 +136,11 @@ func TestExecuteJob_RemotingPath(t *testing.T) {
 			_, _ = w.Write([]byte(`"cookie123"`))
 		case "/remoting/api/agent/get_work":
 			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte(buildWorkJSON))
+			if atomic.AddInt32(&getWorkCalls, 1) == 1 {
+				_, _ = w.Write([]byte(buildWorkJSON))
+			} else {
+				_, _ = w.Write([]byte(`{"type":"NoWork"}`))
+			}
 		default:
 			w.WriteHeader(http.StatusOK)
 		}
@@ -155,31 +164,46 @@ func TestExecuteJob_RemotingPath(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEmpty(t, cookie)
 
-	work, err := client.GetWork(info)
-	require.NoError(t, err)
-	require.NotNil(t, work)
-	build := work.ToBuild(server.URL)
-	require.NotNil(t, build)
-	require.NotNil(t, build.BuildCommand)
-
 	a, err := New(cfg)
 	require.NoError(t, err)
 	a.httpClient = server.Client()
 	a.cookie = cookie
 
+	var completedResult string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	brk := broker.NewRemotingBroker(client, server.URL, 10*time.Millisecond, func(broker.Labels) *protocol.AgentRuntimeInfo {
+		return info
+	})
 	send := func(msg *protocol.Message) {
+		brk.Send(msg)
 		if msg.Action == protocol.ReportCompletedAction {
 			if r := msg.Report(); r != nil {
 				mu.Lock()
 				completedResult = r.Result
 				mu.Unlock()
+				wg.Done()
 			}
 		}
 	}
-	a.handleBuildWithSend(build, send, nil)
+	pool := NewWorkerPool(a, brk, send, broker.Labels{}, 1)
+
+	done := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(done)
+	}()
+
+	wg.Wait()
+	cancel()
+	<-done
 
 	mu.Lock()
 	result := completedResult
 	mu.Unlock()
-	assert.Equal(t, "Passed", result, "remoting get_work -> execute -> report_completed should be Passed")
+	assert.Equal(t, "Passed", result, "remoting Pull -> WorkerPool -> execute -> report_completed should be Passed")
 }
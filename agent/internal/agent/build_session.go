@@ -11,12 +11,26 @@ import (
 	"os"
 	"strings"
 
+	"github.com/d-led/ex_gocd/agent/internal/console"
 	"github.com/d-led/ex_gocd/agent/internal/executor"
+	"github.com/d-led/ex_gocd/agent/internal/executor/plugin"
+	"github.com/d-led/ex_gocd/agent/internal/secrets"
 	"github.com/d-led/ex_gocd/agent/pkg/protocol"
 )
 
-// Ensure BuildSession implements executor.ComposeSession (ProcessCommand).
-var _ executor.ComposeSession = (*BuildSession)(nil)
+// Ensure BuildSession implements executor.ComposeSession (ProcessCommand), BackendSession
+// (default executor.Backend), ContainerSession (Docker container reuse across a compose's
+// sibling subcommands), SecretSession (resolved build secrets), RegistryAuthSession (Docker
+// image pull credentials), and ArtifactStoreSession (Upload/Download destination).
+var (
+	_ executor.ComposeSession       = (*BuildSession)(nil)
+	_ executor.BackendSession       = (*BuildSession)(nil)
+	_ executor.ContainerSession     = (*BuildSession)(nil)
+	_ executor.SecretSession        = (*BuildSession)(nil)
+	_ executor.RegistryAuthSession  = (*BuildSession)(nil)
+	_ executor.ArtifactStoreSession = (*BuildSession)(nil)
+	_ executor.TaskPluginSession    = (*BuildSession)(nil)
+)
 
 const (
 	buildPassed   = "Passed"
@@ -26,32 +40,79 @@ const (
 
 // BuildSession runs the build command tree and reports status.
 type BuildSession struct {
-	buildID     string
-	rootDir     string
-	wd          string
-	command     *protocol.BuildCommand
-	console     io.WriteCloser
-	send        func(*protocol.Message)
-	getReport   func(buildID, jobState, result string) *protocol.Report
-	canceled    func() bool
-	executors   map[string]executor.Executor
-	buildResult string
+	buildID         string
+	rootDir         string
+	wd              string
+	command         *protocol.BuildCommand
+	upload          io.WriteCloser // final raw console sink (e.g. HTTP upload to ConsoleUrl)
+	batcher         *console.Batcher
+	lineWriter      *console.LineWriter
+	redactor        *secrets.RedactingWriter
+	currentProc     string
+	currentCmd      *protocol.BuildCommand
+	send            func(*protocol.Message)
+	getReport       func(buildID, jobState, result string) *protocol.Report
+	canceled        func() bool
+	executors       map[string]executor.Executor
+	buildResult     string
+	backend         executor.Backend
+	dockerContainer string
+	secrets         []secrets.Secret
+	registryUser    string
+	registryPass    string
+	artifactStore   executor.ArtifactStore
+	taskPluginsDir  string
 }
 
 // NewBuildSessionWithConsole creates a build session with an already-created console writer.
-func NewBuildSessionWithConsole(buildID string, command *protocol.BuildCommand, rootDir string, consoleWriter io.WriteCloser, send func(*protocol.Message), getReport func(buildID, jobState, result string) *protocol.Report, canceled func() bool) *BuildSession {
-	return &BuildSession{
-		buildID:     buildID,
-		rootDir:     rootDir,
-		wd:          rootDir,
-		command:     command,
-		console:     consoleWriter,
-		send:        send,
-		getReport:   getReport,
-		canceled:    canceled,
-		executors:   executor.Registry(),
-		buildResult: buildPassed,
+// Structured console output is batched (see internal/console) and sent live via send as
+// appendConsoleLog messages; the assembled full log is written to consoleWriter once, at build
+// completion, rather than streamed line-by-line - see Run.
+// backend is the default executor.Backend for commands that don't override it with their own
+// "backend" attribute (see executor.ResolveBackend); pass executor.Local{} for the agent's
+// original process-per-step behavior.
+// pluginDir is where gocd-agent-plugin-<name> executor plugins are discovered from (see
+// executor/plugin); pass "" to disable plugin discovery for this session.
+// taskPluginsDir is where gocd-task-plugin-<id> Pluggable Task plugins are discovered from (see
+// agent/pkg/plugins, executor.PluginExec); pass "" if the build has no pluginExec steps.
+// buildSecrets are already resolved (see secrets.Resolve) rather than raw protocol.SecretRef,
+// so a provider lookup never blocks the build loop once it's started; their values are masked
+// in all console output (Console) and scoped into Env per-command (see Env, secrets.EnvForCommand).
+// registryUser/registryPass (from config.Config's DockerRegistryUsername/Password) authenticate
+// an image pull for a "docker" backend step with attributes.pull set (see executor.Docker,
+// RegistryAuth); pass "", "" to pull anonymously.
+// artifactStore (see executor.NewArtifactStore) is where "upload"/"download" BuildCommands
+// persist and retrieve artifacts.
+func NewBuildSessionWithConsole(buildID string, command *protocol.BuildCommand, rootDir string, consoleWriter io.WriteCloser, send func(*protocol.Message), getReport func(buildID, jobState, result string) *protocol.Report, canceled func() bool, backend executor.Backend, pluginDir string, taskPluginsDir string, buildSecrets []secrets.Secret, registryUser, registryPass string, artifactStore executor.ArtifactStore) *BuildSession {
+	s := &BuildSession{
+		buildID:        buildID,
+		rootDir:        rootDir,
+		wd:             rootDir,
+		command:        command,
+		upload:         consoleWriter,
+		send:           send,
+		getReport:      getReport,
+		canceled:       canceled,
+		executors:      executor.Registry(),
+		buildResult:    buildPassed,
+		backend:        backend,
+		secrets:        buildSecrets,
+		registryUser:   registryUser,
+		registryPass:   registryPass,
+		artifactStore:  artifactStore,
+		taskPluginsDir: taskPluginsDir,
+	}
+	s.batcher = console.NewBatcher(buildID, send, console.MaxBufferBytesFromEnv())
+	s.lineWriter = console.NewLineWriter(func() string { return s.currentProc }, s.batcher.Append)
+	s.redactor = secrets.NewRedactingWriter(s.lineWriter, secrets.Values(buildSecrets))
+	if pluginDir != "" {
+		if plugins, err := plugin.Discover(pluginDir); err != nil {
+			log.Printf("plugin discovery in %s failed: %v", pluginDir, err)
+		} else {
+			plugin.Register(s.executors, plugins)
+		}
 	}
+	return s
 }
 
 func sanitizeDir(s string) string {
@@ -65,10 +126,21 @@ func sanitizeDir(s string) string {
 
 // Run executes the build command tree, reports status, and closes the console.
 func (s *BuildSession) Run() {
+	defer executor.StopContainer(s)
+	defer s.batcher.Close()
 	defer func() {
-		if c, ok := s.console.(interface{ Close() error }); ok {
-			c.Close()
+		// Flush the redactor first: it may be holding back trailing bytes that could still
+		// complete a secret, and those need to pass through masking before lineWriter emits
+		// its own final partial line.
+		s.redactor.Close()
+		s.lineWriter.Flush()
+		if s.upload == nil {
+			return
+		}
+		if _, err := io.WriteString(s.upload, s.batcher.AssembledText()); err != nil {
+			log.Printf("Build %s: failed to upload console log: %v", s.buildID, err)
 		}
+		s.upload.Close()
 	}()
 
 	log.Printf("Build %s started, root: %s", s.buildID, s.rootDir)
@@ -103,18 +175,67 @@ func (s *BuildSession) ProcessCommand(cmd *protocol.BuildCommand) error {
 	if execFn == nil {
 		return fmt.Errorf("unknown command: %s", cmd.Name)
 	}
+	s.currentProc = cmd.Name
+	s.currentCmd = cmd
 	return execFn(s, cmd)
 }
 
 // WorkingDir implements executor.Session.
 func (s *BuildSession) WorkingDir() string { return s.wd }
 
-// Console implements executor.Session.
-func (s *BuildSession) Console() io.Writer { return s.console }
+// Console implements executor.Session. Output written here has build secrets masked (see
+// internal/secrets.RedactingWriter) before it's split into lines, tagged with the currently
+// running command, and batched to the server as structured LogLine messages - see internal/console.
+func (s *BuildSession) Console() io.Writer { return s.redactor }
 
-// Env returns environment for child processes (current env; can add build-specific later).
+// Env returns environment for child processes: the agent's own environment plus any build
+// secrets scoped to the currently running command (see secrets.EnvForCommand). A command opts
+// into specific secrets regardless of their Scope via an "allowSecrets":[keys...] attribute.
 func (s *BuildSession) Env() []string {
-	return envFromDir(s.wd)
+	env := envFromDir(s.wd)
+	if len(s.secrets) == 0 {
+		return env
+	}
+	name, allow := "", allowSecretsAttr(s.currentCmd)
+	if s.currentCmd != nil {
+		name = s.currentCmd.Name
+	}
+	return append(env, secrets.EnvForCommand(s.secrets, name, allow)...)
+}
+
+// Secrets implements executor.SecretSession.
+func (s *BuildSession) Secrets() []secrets.Secret { return s.secrets }
+
+// RegistryAuth implements executor.RegistryAuthSession.
+func (s *BuildSession) RegistryAuth() (username, password string) { return s.registryUser, s.registryPass }
+
+// ArtifactStore implements executor.ArtifactStoreSession.
+func (s *BuildSession) ArtifactStore() executor.ArtifactStore { return s.artifactStore }
+
+// TaskPluginsDir implements executor.TaskPluginSession.
+func (s *BuildSession) TaskPluginsDir() string { return s.taskPluginsDir }
+
+// allowSecretsAttr reads cmd's "allowSecrets" attribute - a list of secret keys this command
+// opts into regardless of their Scope - tolerating both []string and the []interface{} that
+// JSON unmarshaling into BuildCommand.Attributes produces.
+func allowSecretsAttr(cmd *protocol.BuildCommand) []string {
+	if cmd == nil {
+		return nil
+	}
+	switch v := cmd.Attributes["allowSecrets"].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		keys := make([]string, 0, len(v))
+		for _, k := range v {
+			if s, ok := k.(string); ok {
+				keys = append(keys, s)
+			}
+		}
+		return keys
+	default:
+		return nil
+	}
 }
 
 func envFromDir(dir string) []string {
@@ -129,6 +250,16 @@ func (s *BuildSession) Canceled() bool {
 	return s.canceled()
 }
 
+// Backend implements executor.BackendSession: the session's configured default Backend, used
+// by Exec unless a BuildCommand overrides it with its own "backend" attribute.
+func (s *BuildSession) Backend() executor.Backend { return s.backend }
+
+// DockerContainer implements executor.ContainerSession.
+func (s *BuildSession) DockerContainer() string { return s.dockerContainer }
+
+// SetDockerContainer implements executor.ContainerSession.
+func (s *BuildSession) SetDockerContainer(name string) { s.dockerContainer = name }
+
 func (s *BuildSession) ensureWorkDir() error {
 	return os.MkdirAll(s.wd, 0755)
 }
@@ -0,0 +1,62 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package agent
+
+import (
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func TestMatchesBuild_NoRequirementsAlwaysMatches(t *testing.T) {
+	a := &Agent{config: &config.Config{}}
+
+	if _, ok := a.matchesBuild(&protocol.Build{}); !ok {
+		t.Error("a Build with no RequiredResources/Labels should match any agent")
+	}
+}
+
+func TestMatchesBuild_RequiredResourceMissing(t *testing.T) {
+	a := &Agent{config: &config.Config{Resources: "docker"}}
+
+	reason, ok := a.matchesBuild(&protocol.Build{RequiredResources: []string{"gpu"}})
+	if ok {
+		t.Fatal("build should not match an agent lacking the required resource")
+	}
+	if reason == "" {
+		t.Error("expected a non-empty rejection reason")
+	}
+}
+
+func TestMatchesBuild_RequiredResourcePresent(t *testing.T) {
+	a := &Agent{config: &config.Config{Resources: "docker,gpu"}}
+
+	if _, ok := a.matchesBuild(&protocol.Build{RequiredResources: []string{"gpu"}}); !ok {
+		t.Error("build should match an agent that reports the required resource")
+	}
+}
+
+func TestMatchesBuild_LabelMatchesEnvironmentOrResource(t *testing.T) {
+	a := &Agent{config: &config.Config{Resources: "docker", Environments: "staging"}}
+
+	if _, ok := a.matchesBuild(&protocol.Build{Labels: []string{"staging"}}); !ok {
+		t.Error("label matching an environment should match")
+	}
+	if _, ok := a.matchesBuild(&protocol.Build{Labels: []string{"docker"}}); !ok {
+		t.Error("label matching a resource should match")
+	}
+	if _, ok := a.matchesBuild(&protocol.Build{Labels: []string{"production"}}); ok {
+		t.Error("label matching neither resource nor environment should not match")
+	}
+}
+
+func TestMatchesBuild_ImplicitPlatformResource(t *testing.T) {
+	a := &Agent{config: &config.Config{}}
+	resources := a.config.ResourceList()
+
+	if _, ok := a.matchesBuild(&protocol.Build{RequiredResources: []string{resources[len(resources)-1]}}); !ok {
+		t.Error("the implicit platform:GOOS/GOARCH resource should satisfy a matching RequiredResources entry")
+	}
+}
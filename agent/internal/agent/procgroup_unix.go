@@ -0,0 +1,38 @@
+//go:build !windows
+
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package agent
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup configures c to start as the leader of its own process group (Setpgid), so
+// terminateProcessGroup/killProcessGroup can signal the whole tree - a shell spawning make,
+// docker, etc. - instead of just the directly-spawned leader.
+func setProcessGroup(c *exec.Cmd) {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.Setpgid = true
+}
+
+// terminateProcessGroup sends SIGTERM to c's whole process group (the negative pid convention),
+// giving it a chance to shut down cleanly before killProcessGroup forces it.
+func terminateProcessGroup(c *exec.Cmd) error {
+	if c.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-c.Process.Pid, syscall.SIGTERM)
+}
+
+// killProcessGroup sends SIGKILL to c's whole process group.
+func killProcessGroup(c *exec.Cmd) error {
+	if c.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-c.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package agent
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup configures c to start in a new process group (CREATE_NEW_PROCESS_GROUP) so
+// killProcessGroup can tear down the whole tree instead of just the leader.
+func setProcessGroup(c *exec.Cmd) {
+	if c.SysProcAttr == nil {
+		c.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	c.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// terminateProcessGroup has no SIGTERM equivalent for a Windows process group; killProcessGroup
+// is the only option, so the SIGTERM-then-grace-then-SIGKILL sequence in waitWithCancellation
+// collapses to an immediate kill here.
+func terminateProcessGroup(c *exec.Cmd) error {
+	return killProcessGroup(c)
+}
+
+// killProcessGroup kills c's leader process. Combined with CREATE_NEW_PROCESS_GROUP this is the
+// best-effort equivalent of a Unix process-group kill without shelling out to `taskkill /T`.
+func killProcessGroup(c *exec.Cmd) error {
+	if c.Process == nil {
+		return nil
+	}
+	return c.Process.Kill()
+}
@@ -7,34 +7,31 @@ import (
 	"testing"
 	"time"
 
+	"github.com/d-led/ex_gocd/agent/internal/backoff"
 	"github.com/stretchr/testify/assert"
 )
 
-// TestExponentialBackoff verifies reconnection uses exponential backoff
+// TestExponentialBackoff verifies the reconnect loop's no-jitter schedule matches the agent's
+// historical fixed delays (2s, 4s, 8s, ... capped at 60s). The math itself now lives in
+// backoff.Strategy, shared with registration's retry loop.
 func TestExponentialBackoff(t *testing.T) {
-	baseDelay := 2 * time.Second
-	maxDelay := 60 * time.Second
-	
+	reconnectBackoff := backoff.Strategy{Base: 2 * time.Second, Max: 60 * time.Second, Jitter: backoff.NoJitter}
+
 	tests := []struct {
 		attempt       int
 		expectedDelay time.Duration
 	}{
-		{0, 2 * time.Second},   // 2^0 * 2s = 2s
-		{1, 4 * time.Second},   // 2^1 * 2s = 4s
-		{2, 8 * time.Second},   // 2^2 * 2s = 8s
-		{3, 16 * time.Second},  // 2^3 * 2s = 16s
-		{4, 32 * time.Second},  // 2^4 * 2s = 32s
-		{5, 60 * time.Second},  // 2^5 * 2s = 64s, capped at 60s
-		{6, 60 * time.Second},  // 2^6 * 2s = 128s, capped at 60s
+		{0, 2 * time.Second},  // 2^0 * 2s = 2s
+		{1, 4 * time.Second},  // 2^1 * 2s = 4s
+		{2, 8 * time.Second},  // 2^2 * 2s = 8s
+		{3, 16 * time.Second}, // 2^3 * 2s = 16s
+		{4, 32 * time.Second}, // 2^4 * 2s = 32s
+		{5, 60 * time.Second}, // 2^5 * 2s = 64s, capped at 60s
+		{6, 60 * time.Second}, // 2^6 * 2s = 128s, capped at 60s
 	}
-	
+
 	for _, tt := range tests {
-		// Calculate exponential backoff (matching agent logic)
-		retryDelay := baseDelay * time.Duration(1<<uint(tt.attempt))
-		if retryDelay > maxDelay {
-			retryDelay = maxDelay
-		}
-		
+		retryDelay := reconnectBackoff.Next(tt.attempt)
 		assert.Equal(t, tt.expectedDelay, retryDelay,
 			"Attempt %d: expected %v, got %v", tt.attempt, tt.expectedDelay, retryDelay)
 	}
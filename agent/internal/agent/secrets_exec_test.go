@@ -0,0 +1,83 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunOneCommand_ScopesSecretsIntoCommandEnv proves a Build's Secrets actually reach the
+// process running its BuildCommand. Before this, resolution and scoped env injection only
+// existed inside BuildSession, which nothing in production ever constructed - a Build.Secrets
+// entry silently had no effect on the real exec path.
+func TestRunOneCommand_ScopesSecretsIntoCommandEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses /bin/sh")
+	}
+	t.Setenv("AGENT_TEST_SECRET_VALUE", "s3kr1t")
+
+	dir := t.TempDir()
+	a := newTestAgent(&config.Config{WorkingDir: dir})
+
+	outFile := filepath.Join(dir, "out.txt")
+	build := &protocol.Build{
+		BuildId: "b1",
+		Secrets: []protocol.SecretRef{{Key: "MY_SECRET", Value: "AGENT_TEST_SECRET_VALUE"}},
+		BuildCommand: &protocol.BuildCommand{
+			Name:    protocol.CommandExec,
+			Command: "/bin/sh",
+			Args:    []string{"-c", "printf %s \"$MY_SECRET\" > " + outFile},
+		},
+	}
+
+	require.NoError(t, a.runBuildCommand(context.Background(), build))
+
+	got, err := os.ReadFile(outFile)
+	require.NoError(t, err)
+	require.Equal(t, "s3kr1t", string(got))
+}
+
+// TestRunOneCommand_DispatchesDiscoveredPlugin proves a BuildCommand naming a discovered
+// gocd-agent-plugin-<name> executor actually runs through it. Before this, such a command had
+// no "command" of its own (the plugin is the command), so it fell through runOneCommand's
+// cmd.Command == "" early-return and silently no-opped as a "successful" step.
+func TestRunOneCommand_DispatchesDiscoveredPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell-script plugin")
+	}
+	pluginDir := t.TempDir()
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "ran.txt")
+
+	script := "#!/bin/sh\n" +
+		"if [ \"$1\" = \"--describe\" ]; then\n" +
+		"  echo '{\"name\":\"marker\",\"version\":\"1\",\"commands\":[\"leaveMarker\"]}'\n" +
+		"  exit 0\n" +
+		"fi\n" +
+		"cat > /dev/null\n" +
+		"touch " + marker + "\n" +
+		"echo '{\"type\":\"status\",\"message\":\"ok\"}'\n"
+	path := filepath.Join(pluginDir, "gocd-agent-plugin-marker")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+
+	a := newTestAgent(&config.Config{WorkingDir: dir, PluginDir: pluginDir})
+
+	build := &protocol.Build{
+		BuildId:      "b2",
+		BuildCommand: &protocol.BuildCommand{Name: "leaveMarker"},
+	}
+
+	require.NoError(t, a.runBuildCommand(context.Background(), build))
+
+	_, err := os.Stat(marker)
+	require.NoError(t, err, "plugin should have run and left its marker file")
+}
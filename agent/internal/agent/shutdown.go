@@ -0,0 +1,108 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Graceful shutdown: Start installs a signal handler that drains or fast-cancels an in-flight
+// build before the agent disconnects.
+
+package agent
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const drainPollInterval = 200 * time.Millisecond
+
+// handleSignals waits for SIGINT/SIGTERM/SIGHUP or ctx.Done(), whichever comes first. On a
+// signal it drains the current build (see drain) and then calls stop once; ctx.Done() on its own
+// never calls stop. SIGHUP (the
+// agent's parent process is gone, e.g. a terminal closing) waits up to a.config.DrainTimeout for
+// the build to finish on its own; SIGINT/SIGTERM (a typical process-manager shutdown) cancel it
+// immediately - mirroring how ssh-tunnel style agents separate a lost parent (drain, clean up
+// what's forwarded) from a requested teardown (fast exit). The signal handler is torn down once
+// ctx is done, whether that's because of a signal or some other shutdown path, so Start callers
+// never leak the underlying signal.Notify registration.
+func (a *Agent) handleSignals(ctx context.Context, stop func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sigCh)
+		select {
+		case sig, ok := <-sigCh:
+			if !ok {
+				return
+			}
+			log.Printf("Received %s, draining...", sig)
+			a.drain(sig == syscall.SIGHUP)
+			stop()
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// drain stops the agent from accepting new builds (BuildAction is refused once a.state is
+// "Draining") and, for SIGHUP, waits up to a.config.DrainTimeout for all in-flight builds to
+// finish on their own. If there's nothing in progress, or the wait times out (or wait is false,
+// for a fast SIGINT/SIGTERM shutdown), every build still in a.activeBuilds is canceled;
+// handleBuildWithSend's own completion path then reports the final "Cancelled" status for each,
+// which drain waits for (again bounded by DrainTimeout) before returning.
+func (a *Agent) drain(wait bool) {
+	a.buildMu.Lock()
+	a.state = "Draining"
+	building := len(a.activeBuilds) > 0
+	a.buildMu.Unlock()
+
+	if !building {
+		log.Println("Draining: no build in progress, shutting down")
+		return
+	}
+
+	finished := false
+	if wait {
+		log.Printf("Draining: waiting up to %v for in-flight builds to finish", a.config.DrainTimeout)
+		finished = a.waitForBuildDone(a.config.DrainTimeout)
+	}
+	if finished {
+		log.Println("Draining: builds finished on their own, shutting down")
+		return
+	}
+
+	a.buildMu.Lock()
+	cancelFns := make([]context.CancelFunc, 0, len(a.activeBuilds))
+	for _, cancelFn := range a.activeBuilds {
+		cancelFns = append(cancelFns, cancelFn)
+	}
+	a.buildMu.Unlock()
+	if len(cancelFns) > 0 {
+		log.Printf("Draining: canceling %d in-progress build(s)", len(cancelFns))
+		for _, cancelFn := range cancelFns {
+			cancelFn()
+		}
+	}
+	if !a.waitForBuildDone(a.config.DrainTimeout) {
+		log.Println("Draining: builds did not report Cancelled in time, shutting down anyway")
+	}
+}
+
+// waitForBuildDone polls until no build is in progress or timeout elapses, returning whether the
+// build finished within timeout.
+func (a *Agent) waitForBuildDone(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for a.isBuilding() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+	return true
+}
+
+// isBuilding reports whether any build is currently in progress.
+func (a *Agent) isBuilding() bool {
+	a.buildMu.Lock()
+	defer a.buildMu.Unlock()
+	return len(a.activeBuilds) > 0
+}
@@ -0,0 +1,116 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+)
+
+func TestDrain_NoBuildInProgressReturnsImmediately(t *testing.T) {
+	a := &Agent{config: &config.Config{DrainTimeout: time.Minute}, state: "Idle"}
+
+	done := make(chan struct{})
+	go func() { a.drain(true); close(done) }()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain did not return for an idle agent")
+	}
+
+	if a.state != "Draining" {
+		t.Errorf("state = %q, want Draining", a.state)
+	}
+}
+
+func TestDrain_WaitsBeforeCanceling(t *testing.T) {
+	a := &Agent{config: &config.Config{DrainTimeout: 50 * time.Millisecond}, state: "Building"}
+	canceled := make(chan struct{})
+	a.activeBuilds = map[string]context.CancelFunc{"build-1": func() { close(canceled) }}
+
+	done := make(chan struct{})
+	go func() { a.drain(true); close(done) }()
+
+	// The build finishes on its own just after the wait starts - drain should see that and never
+	// call its cancel func.
+	time.Sleep(10 * time.Millisecond)
+	a.buildMu.Lock()
+	delete(a.activeBuilds, "build-1")
+	a.buildMu.Unlock()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain did not return")
+	}
+	select {
+	case <-canceled:
+		t.Error("drain should not have canceled a build that finished on its own")
+	default:
+	}
+}
+
+func TestDrain_CancelsAfterTimeout(t *testing.T) {
+	a := &Agent{config: &config.Config{DrainTimeout: 30 * time.Millisecond}, state: "Building"}
+	canceled := make(chan struct{})
+	a.activeBuilds = map[string]context.CancelFunc{"build-1": func() {
+		close(canceled)
+		a.buildMu.Lock()
+		delete(a.activeBuilds, "build-1")
+		a.buildMu.Unlock()
+	}}
+
+	done := make(chan struct{})
+	go func() { a.drain(true); close(done) }()
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("drain never canceled the build still running past DrainTimeout")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drain did not return after canceling")
+	}
+}
+
+func TestDrain_FastCancelsWithoutWaiting(t *testing.T) {
+	a := &Agent{config: &config.Config{DrainTimeout: time.Minute}, state: "Building"}
+	canceled := make(chan struct{})
+	a.activeBuilds = map[string]context.CancelFunc{"build-1": func() {
+		close(canceled)
+		a.buildMu.Lock()
+		delete(a.activeBuilds, "build-1")
+		a.buildMu.Unlock()
+	}}
+
+	go a.drain(false) // SIGINT/SIGTERM: no wait, even though DrainTimeout is a minute
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("drain(false) should cancel immediately without waiting for DrainTimeout")
+	}
+}
+
+func TestHandleSignals_StopsOnContextDone(t *testing.T) {
+	a := &Agent{config: &config.Config{DrainTimeout: time.Minute}, state: "Idle"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stopped := make(chan struct{})
+	a.handleSignals(ctx, func() { close(stopped) })
+	cancel()
+
+	// stop must not be called just because ctx finished without a signal.
+	select {
+	case <-stopped:
+		t.Error("stop should only be called in response to a signal")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// WorkerPool runs several handleBuildWithSend goroutines concurrently against a broker.Broker,
+// so the remoting (poll-based) path can honor cfg.MaxConcurrentJobs instead of a single
+// get_work -> execute -> repeat loop. TestExecuteJob_RemotingPath drives builds through it.
+
+package agent
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/backoff"
+	"github.com/d-led/ex_gocd/agent/internal/broker"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// pullBackoff spaces out retries after a failed Pull (e.g. the server is down), the same way
+// the reconnect loop, registration, and console writer already do - full jitter because up to
+// Concurrency workers can hit a failing Pull at once and shouldn't all retry in lockstep.
+var pullBackoff = backoff.Strategy{Base: 2 * time.Second, Max: 60 * time.Second, Jitter: backoff.FullJitter}
+
+// WorkerPool pulls builds from a broker.Broker and runs up to Concurrency of them at once,
+// through a.handleBuildWithSend. Ping responses are dispatched here too (see DispatchPing);
+// since a classic ping names no build, they apply to every build in a.activeBuilds, unlike the
+// WebSocket path's CancelBuildAction, which cancels one build by its buildId.
+type WorkerPool struct {
+	agent       *Agent
+	brk         broker.Broker
+	send        func(*protocol.Message)
+	filter      broker.Labels
+	concurrency int
+}
+
+// NewWorkerPool builds a WorkerPool bounded by concurrency (cfg.MaxConcurrentJobs; treated as 1
+// if <= 0, matching a classic single-job agent). send reports each build's status - for a
+// RemotingBroker, pass its own Send method, which translates reports into report_* calls.
+func NewWorkerPool(a *Agent, brk broker.Broker, send func(*protocol.Message), filter broker.Labels, concurrency int) *WorkerPool {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &WorkerPool{agent: a, brk: brk, send: send, filter: filter, concurrency: concurrency}
+}
+
+// Run starts Concurrency worker goroutines, each looping Pull -> handleBuildWithSend until ctx
+// is cancelled, and blocks until they've all returned.
+func (p *WorkerPool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(p.concurrency)
+	for i := 0; i < p.concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			p.runWorker(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// runWorker is a single worker's Pull -> (optionally reject) -> execute loop.
+func (p *WorkerPool) runWorker(ctx context.Context) {
+	attempt := 0
+	for {
+		build, err := p.brk.Pull(ctx, p.filter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("worker pool: pull failed: %v", err)
+			select {
+			case <-time.After(pullBackoff.Next(attempt)):
+			case <-ctx.Done():
+				return
+			}
+			attempt++
+			continue
+		}
+		attempt = 0
+
+		if reason, ok := p.agent.matchesBuild(build); !ok {
+			log.Printf("worker pool: rejecting build %s: %s", build.BuildId, reason)
+			p.brk.Nack(build.BuildId, reason)
+			continue
+		}
+
+		p.agent.handleBuildWithSend(build, p.send, p.brk)
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+	}
+}
+
+// DispatchPing reacts to a ping instruction ("NONE", "CANCEL", "KILL_RUNNING_TASKS") from
+// remoting.Client.Ping. The classic ping protocol carries no specific buildID, so CANCEL and
+// KILL_RUNNING_TASKS both cancel every build this pool currently has in flight - the closest
+// per-job analogue available, short of the server naming a job, since cancelling nothing a build
+// isn't running and cancelling everything are the only two instructions it can send.
+func (p *WorkerPool) DispatchPing(instruction string) {
+	switch instruction {
+	case "CANCEL", "KILL_RUNNING_TASKS":
+	default:
+		return
+	}
+
+	p.agent.buildMu.Lock()
+	cancelFns := make([]context.CancelFunc, 0, len(p.agent.activeBuilds))
+	for _, cancelFn := range p.agent.activeBuilds {
+		cancelFns = append(cancelFns, cancelFn)
+	}
+	p.agent.buildMu.Unlock()
+
+	if len(cancelFns) > 0 {
+		log.Printf("worker pool: %s ping received, cancelling %d in-flight build(s)", instruction, len(cancelFns))
+	}
+	for _, cancelFn := range cancelFns {
+		cancelFn()
+	}
+}
+
+// PingLoop calls ping on every interval until ctx is cancelled, dispatching each response via
+// DispatchPing. Run this alongside Run (e.g. in its own goroutine) to keep the remoting path's
+// cancellation responsive without a dedicated WebSocket connection to push CancelBuildAction.
+func (p *WorkerPool) PingLoop(ctx context.Context, ping func() (string, error), interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			instruction, err := ping()
+			if err != nil {
+				log.Printf("worker pool: ping failed: %v", err)
+				continue
+			}
+			p.DispatchPing(instruction)
+		}
+	}
+}
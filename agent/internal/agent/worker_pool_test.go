@@ -0,0 +1,149 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package agent
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/broker"
+	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/internal/logging"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// fakeBroker hands out builds from a fixed queue, one per Pull call, then blocks until ctx is
+// cancelled - mirroring RemotingBroker's "poll until something shows up, or ctx ends" contract.
+type fakeBroker struct {
+	mu     sync.Mutex
+	builds []*protocol.Build
+	acked  []string
+	nacked []string
+}
+
+func (f *fakeBroker) Pull(ctx context.Context, filter broker.Labels) (*protocol.Build, error) {
+	f.mu.Lock()
+	if len(f.builds) > 0 {
+		b := f.builds[0]
+		f.builds = f.builds[1:]
+		f.mu.Unlock()
+		return b, nil
+	}
+	f.mu.Unlock()
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeBroker) Ack(buildID string) {
+	f.mu.Lock()
+	f.acked = append(f.acked, buildID)
+	f.mu.Unlock()
+}
+
+func (f *fakeBroker) Nack(buildID string, reason string) {
+	f.mu.Lock()
+	f.nacked = append(f.nacked, buildID)
+	f.mu.Unlock()
+}
+
+// newTestAgent builds a bare Agent suitable for exercising WorkerPool/matchesBuild, bypassing
+// New's UUID-file/registrar setup (which needs a real ConfigDir) - matching label_match_test.go's
+// existing &Agent{config: ...} convention.
+func newTestAgent(cfg *config.Config) *Agent {
+	return &Agent{
+		config:       cfg,
+		state:        "Idle",
+		logger:       logging.New(""),
+		activeBuilds: make(map[string]context.CancelFunc),
+	}
+}
+
+// TestWorkerPool_RunsBuildAndAcksIt ensures a Pulled build runs to completion and is Acked.
+func TestWorkerPool_RunsBuildAndAcksIt(t *testing.T) {
+	a := newTestAgent(&config.Config{})
+
+	var completed string
+	var mu sync.Mutex
+	send := func(msg *protocol.Message) {
+		if msg.Action == protocol.ReportCompletedAction {
+			mu.Lock()
+			completed = msg.Report().Result
+			mu.Unlock()
+		}
+	}
+
+	brk := &fakeBroker{builds: []*protocol.Build{{BuildId: "job-1"}}}
+	pool := NewWorkerPool(a, brk, send, broker.Labels{}, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	pool.Run(ctx)
+
+	mu.Lock()
+	result := completed
+	mu.Unlock()
+	if result != "Passed" {
+		t.Errorf("completed result = %q, want Passed", result)
+	}
+	if len(brk.acked) != 1 || brk.acked[0] != "job-1" {
+		t.Errorf("acked = %v, want [job-1]", brk.acked)
+	}
+}
+
+// TestWorkerPool_NacksBuildThatDoesNotMatch ensures a Pulled build a.matchesBuild rejects is
+// Nacked rather than executed.
+func TestWorkerPool_NacksBuildThatDoesNotMatch(t *testing.T) {
+	a := newTestAgent(&config.Config{Resources: "docker"})
+
+	ran := false
+	send := func(msg *protocol.Message) { ran = true }
+
+	brk := &fakeBroker{builds: []*protocol.Build{{BuildId: "job-1", RequiredResources: []string{"gpu"}}}}
+	pool := NewWorkerPool(a, brk, send, broker.Labels{}, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	pool.Run(ctx)
+
+	if ran {
+		t.Error("a build rejected by matchesBuild should never run")
+	}
+	if len(brk.nacked) != 1 || brk.nacked[0] != "job-1" {
+		t.Errorf("nacked = %v, want [job-1]", brk.nacked)
+	}
+}
+
+// TestWorkerPool_DispatchPing_CancelsInFlightBuilds ensures a CANCEL/KILL_RUNNING_TASKS ping
+// instruction cancels every build currently tracked in a.activeBuilds.
+func TestWorkerPool_DispatchPing_CancelsInFlightBuilds(t *testing.T) {
+	a := newTestAgent(&config.Config{})
+	canceled := make(chan struct{})
+	a.activeBuilds["job-1"] = func() { close(canceled) }
+
+	pool := NewWorkerPool(a, &fakeBroker{}, func(*protocol.Message) {}, broker.Labels{}, 1)
+	pool.DispatchPing("CANCEL")
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("DispatchPing(CANCEL) did not cancel the in-flight build")
+	}
+}
+
+// TestWorkerPool_DispatchPing_IgnoresNone ensures a NONE instruction leaves active builds running.
+func TestWorkerPool_DispatchPing_IgnoresNone(t *testing.T) {
+	a := newTestAgent(&config.Config{})
+	canceled := false
+	a.activeBuilds["job-1"] = func() { canceled = true }
+
+	pool := NewWorkerPool(a, &fakeBroker{}, func(*protocol.Message) {}, broker.Labels{}, 1)
+	pool.DispatchPing("NONE")
+
+	if canceled {
+		t.Error("a NONE ping instruction should not cancel any build")
+	}
+}
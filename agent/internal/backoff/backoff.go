@@ -0,0 +1,88 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Shared exponential backoff: registration retries and the agent reconnect loop both need
+// "wait longer each failed attempt, up to a cap" without duplicating the math or the
+// context-aware sleep.
+
+package backoff
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// JitterMode selects how Next spreads retries across their computed cap.
+type JitterMode int
+
+const (
+	// NoJitter always returns the deterministic cap for an attempt (base * multiplier^attempt,
+	// clamped to Max). Useful for tests and for callers that already space out retries.
+	NoJitter JitterMode = iota
+	// FullJitter samples uniformly from [0, cap], AWS-style, so a fleet of agents retrying the
+	// same failure doesn't all wake up and hammer the server at the same instant.
+	FullJitter
+)
+
+// Strategy describes an exponential backoff: Base is the attempt-0 delay, Max caps the delay
+// regardless of attempt count, and Multiplier controls the growth rate (defaults to 2 when
+// zero). Jitter selects NoJitter or FullJitter.
+type Strategy struct {
+	Base       time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     JitterMode
+}
+
+// Next returns the delay to use before the given retry attempt (0-indexed).
+func (s Strategy) Next(attempt int) time.Duration {
+	cap := s.capForAttempt(attempt)
+	if s.Jitter == NoJitter || cap <= 0 {
+		return cap
+	}
+	return time.Duration(rand.Int63n(int64(cap) + 1))
+}
+
+// capForAttempt computes base * multiplier^attempt, clamped to [0, Max].
+func (s Strategy) capForAttempt(attempt int) time.Duration {
+	base := s.Base
+	if base <= 0 {
+		base = time.Second
+	}
+	multiplier := s.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	scaled := float64(base) * math.Pow(multiplier, float64(attempt))
+	if s.Max > 0 && (scaled > float64(s.Max) || math.IsInf(scaled, 1)) {
+		return s.Max
+	}
+	return time.Duration(scaled)
+}
+
+// Do calls fn, retrying with this Strategy's delays between attempts as long as retryable(err)
+// returns true. It returns the first nil error, the first non-retryable error, or ctx.Err()
+// if ctx is cancelled while waiting between attempts - it never blocks shutdown for a full
+// sleep the way an unconditional time.Sleep would.
+func (s Strategy) Do(ctx context.Context, fn func() error, retryable func(error) bool) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if retryable != nil && !retryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(s.Next(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
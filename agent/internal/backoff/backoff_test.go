@@ -0,0 +1,108 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNext_NoJitterIsDeterministic mirrors the reconnection loop's original fixed schedule:
+// base * 2^attempt, capped at max.
+func TestNext_NoJitterIsDeterministic(t *testing.T) {
+	s := Strategy{Base: 2 * time.Second, Max: 60 * time.Second, Jitter: NoJitter}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 2 * time.Second},
+		{1, 4 * time.Second},
+		{2, 8 * time.Second},
+		{3, 16 * time.Second},
+		{4, 32 * time.Second},
+		{5, 60 * time.Second}, // 64s capped at 60s
+		{6, 60 * time.Second}, // 128s capped at 60s
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, s.Next(tt.attempt), "attempt %d", tt.attempt)
+	}
+}
+
+// TestNext_FullJitterStaysWithinCap samples the distribution and asserts every sample lands
+// in [0, cap].
+func TestNext_FullJitterStaysWithinCap(t *testing.T) {
+	s := Strategy{Base: 100 * time.Millisecond, Max: time.Second, Jitter: FullJitter}
+	for attempt := 0; attempt < 5; attempt++ {
+		cap := s.capForAttempt(attempt)
+		for i := 0; i < 200; i++ {
+			got := s.Next(attempt)
+			assert.True(t, got >= 0 && got <= cap, "attempt %d: got %v, want in [0, %v]", attempt, got, cap)
+		}
+	}
+}
+
+func TestDo_SucceedsWithoutRetry(t *testing.T) {
+	s := Strategy{Base: time.Millisecond, Max: time.Millisecond, Jitter: NoJitter}
+	calls := 0
+	err := s.Do(context.Background(), func() error {
+		calls++
+		return nil
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_RetriesUntilSuccess(t *testing.T) {
+	s := Strategy{Base: time.Millisecond, Max: 2 * time.Millisecond, Jitter: NoJitter}
+	calls := 0
+	err := s.Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("pending")
+		}
+		return nil
+	}, func(error) bool { return true })
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDo_StopsOnNonRetryableError(t *testing.T) {
+	s := Strategy{Base: time.Millisecond, Max: time.Millisecond, Jitter: NoJitter}
+	wantErr := errors.New("fatal")
+	calls := 0
+	err := s.Do(context.Background(), func() error {
+		calls++
+		return wantErr
+	}, func(error) bool { return false })
+	assert.Equal(t, wantErr, err)
+	assert.Equal(t, 1, calls)
+}
+
+// TestDo_ContextCancelAbortsPendingSleepPromptly ensures a cancelled context interrupts a
+// pending sleep instead of blocking for the full backoff duration.
+func TestDo_ContextCancelAbortsPendingSleepPromptly(t *testing.T) {
+	s := Strategy{Base: time.Hour, Max: time.Hour, Jitter: NoJitter}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Do(ctx, func() error { return errors.New("always fails") }, func(error) bool { return true })
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("Do did not abort promptly after ctx cancellation")
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// broker sits between the remoting poll loop and job execution - borrowed from Drone's
+// queue/broker split, where a Broker filters pending items by labels and hands work to workers -
+// so agent.WorkerPool can run several builds concurrently instead of a single inline
+// get_work -> execute call.
+
+package broker
+
+import (
+	"context"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Labels is the set of resource/environment labels a worker advertises when it Pulls, so a
+// Broker can pre-filter pending work before Agent.matchesBuild makes the authoritative decision.
+type Labels struct {
+	Resources    []string
+	Environments []string
+}
+
+// Broker hands pending work to workers and tracks what's in flight. Pull blocks until a Build
+// matching filter is available or ctx is cancelled; Ack/Nack close out a Build Pull previously
+// returned, once a worker is done with it (successfully or not).
+type Broker interface {
+	// Pull returns the next Build this worker (advertising filter) is allowed to run.
+	Pull(ctx context.Context, filter Labels) (*protocol.Build, error)
+	// Ack reports that buildID finished running and is no longer in flight.
+	Ack(buildID string)
+	// Nack reports that buildID could not be run (e.g. rejected by matchesBuild after Pull).
+	Nack(buildID string, reason string)
+}
@@ -0,0 +1,124 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package broker
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/remoting"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// RuntimeInfoFunc builds the AgentRuntimeInfo a RemotingBroker sends with each get_work poll,
+// given the Labels the calling worker advertised - see agent.Agent.getRuntimeInfo.
+type RuntimeInfoFunc func(filter Labels) *protocol.AgentRuntimeInfo
+
+// RemotingBroker adapts remoting.Client's get_work polling to the Broker interface: Pull polls
+// on pollInterval until the server hands back a BuildWork or ctx is cancelled.
+type RemotingBroker struct {
+	client        *remoting.Client
+	serverBaseURL string
+	pollInterval  time.Duration
+	runtimeInfo   RuntimeInfoFunc
+
+	// jobIDs remembers the remoting.JobIdentifier each Pull returned, keyed by the resulting
+	// Build's BuildId, so Send can translate a later report_* protocol.Message back into the
+	// shape remoting.Client.ReportCurrentStatus/ReportCompleting/ReportCompleted expects -
+	// protocol.Build only carries the flattened BuildId string (see remoting.Work.ToBuild), not
+	// the structured JobIdentifier, since pkg/protocol must not depend on internal/remoting.
+	// Cleared by Ack/Nack once the build is no longer in flight.
+	mu     sync.Mutex
+	jobIDs map[string]*remoting.JobIdentifier
+}
+
+// NewRemotingBroker wraps client in the Broker interface. serverBaseURL resolves a
+// FetchArtifactBuilder's relative URLs (see remoting.Work.ToBuild); runtimeInfo builds the
+// AgentRuntimeInfo sent with each poll.
+func NewRemotingBroker(client *remoting.Client, serverBaseURL string, pollInterval time.Duration, runtimeInfo RuntimeInfoFunc) *RemotingBroker {
+	return &RemotingBroker{
+		client:        client,
+		serverBaseURL: serverBaseURL,
+		pollInterval:  pollInterval,
+		runtimeInfo:   runtimeInfo,
+		jobIDs:        make(map[string]*remoting.JobIdentifier),
+	}
+}
+
+// Pull polls get_work every pollInterval until the server assigns a build or ctx is cancelled;
+// a get_work error is returned at once.
+func (b *RemotingBroker) Pull(ctx context.Context, filter Labels) (*protocol.Build, error) {
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+	for {
+		work, err := b.client.GetWork(b.runtimeInfo(filter))
+		if err != nil {
+			return nil, err
+		}
+		if work != nil {
+			if build := work.ToBuild(b.serverBaseURL); build != nil {
+				if work.Assignment != nil && work.Assignment.JobIdentifier != nil {
+					b.mu.Lock()
+					b.jobIDs[build.BuildId] = work.Assignment.JobIdentifier
+					b.mu.Unlock()
+				}
+				return build, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ack is a no-op beyond forgetting buildID's JobIdentifier: the classic remoting protocol has no
+// get_work acknowledgement beyond the report_* status calls Send already issues as the build runs.
+func (b *RemotingBroker) Ack(buildID string) {
+	b.mu.Lock()
+	delete(b.jobIDs, buildID)
+	b.mu.Unlock()
+}
+
+// Nack forgets buildID's JobIdentifier for the same reason as Ack; a rejected build simply isn't
+// retried by this agent - the server reassigns it to another one on its own poll cycle.
+func (b *RemotingBroker) Nack(buildID string, reason string) {
+	b.mu.Lock()
+	delete(b.jobIDs, buildID)
+	b.mu.Unlock()
+}
+
+// Send implements the send func agent.Agent.handleBuildWithSend expects: it translates a
+// report_* protocol.Message into the matching remoting.Client report_current_status/
+// report_completing/report_completed call, using the JobIdentifier Pull captured for this build.
+// A message for a BuildId Pull never returned (or already Acked/Nacked) is silently dropped -
+// this mirrors the WebSocket path, where a.conn.Send has no delivery guarantee either.
+func (b *RemotingBroker) Send(msg *protocol.Message) {
+	report := msg.Report()
+	if report == nil {
+		return
+	}
+	b.mu.Lock()
+	jobID := b.jobIDs[report.BuildId]
+	b.mu.Unlock()
+	if jobID == nil {
+		return
+	}
+
+	var err error
+	switch msg.Action {
+	case protocol.ReportCompletedAction:
+		err = b.client.ReportCompleted(report.AgentRuntimeInfo, jobID, report.Result, report.TestResults)
+	case protocol.ReportCompletingAction:
+		err = b.client.ReportCompleting(report.AgentRuntimeInfo, jobID, report.Result)
+	default:
+		err = b.client.ReportCurrentStatus(report.AgentRuntimeInfo, jobID, report.JobState)
+	}
+	if err != nil {
+		log.Printf("broker: %s for build %s: %v", msg.Action, report.BuildId, err)
+	}
+}
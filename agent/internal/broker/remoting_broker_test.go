@@ -0,0 +1,199 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package broker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/internal/remoting"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const buildWorkJSON = `{
+	"type": "BuildWork",
+	"assignment": {
+		"buildWorkingDirectory": {"path": "/tmp/build"},
+		"jobIdentifier": {
+			"pipelineName": "p", "pipelineCounter": 1, "pipelineLabel": "1",
+			"stageName": "s", "stageCounter": "1", "buildName": "job1", "buildId": 42
+		},
+		"builders": [
+			{"type": "CommandBuilderWithArgList", "command": "echo", "args": ["hello"]}
+		]
+	}
+}`
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *remoting.Client {
+	t.Helper()
+	dir := t.TempDir()
+	configDir := filepath.Join(dir, "config")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "token"), []byte("test-token"), 0644))
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	cfg := &config.Config{ServerURL: serverURL, WorkDir: dir, ConfigDir: configDir, UUID: "broker-test"}
+	client, err := remoting.NewClient(cfg, server.Client())
+	require.NoError(t, err)
+	return client
+}
+
+// TestRemotingBroker_Pull_ReturnsBuildOnFirstWork ensures Pull returns as soon as get_work hands
+// back a BuildWork, without waiting out pollInterval.
+func TestRemotingBroker_Pull_ReturnsBuildOnFirstWork(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/remoting/api/agent/get_work" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(buildWorkJSON))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	b := NewRemotingBroker(client, "https://gocd.example.com", time.Hour, func(Labels) *protocol.AgentRuntimeInfo {
+		return &protocol.AgentRuntimeInfo{}
+	})
+
+	build, err := b.Pull(context.Background(), Labels{})
+	require.NoError(t, err)
+	require.NotNil(t, build)
+	assert.Equal(t, "p/1/s/1/job1", build.BuildId)
+}
+
+// TestRemotingBroker_Pull_PollsUntilWorkArrives ensures Pull keeps polling (on pollInterval) while
+// the server reports NoWork, then returns once a BuildWork shows up.
+func TestRemotingBroker_Pull_PollsUntilWorkArrives(t *testing.T) {
+	attempts := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/remoting/api/agent/get_work" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		attempts++
+		w.Header().Set("Content-Type", "application/json")
+		if attempts < 3 {
+			_, _ = w.Write([]byte(`{"type":"NoWork"}`))
+			return
+		}
+		_, _ = w.Write([]byte(buildWorkJSON))
+	})
+
+	b := NewRemotingBroker(client, "https://gocd.example.com", 5*time.Millisecond, func(Labels) *protocol.AgentRuntimeInfo {
+		return &protocol.AgentRuntimeInfo{}
+	})
+
+	build, err := b.Pull(context.Background(), Labels{})
+	require.NoError(t, err)
+	require.NotNil(t, build)
+	assert.GreaterOrEqual(t, attempts, 3)
+}
+
+// TestRemotingBroker_Pull_StopsOnContextCancel ensures Pull returns ctx.Err() rather than
+// polling forever when the server never has work.
+func TestRemotingBroker_Pull_StopsOnContextCancel(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"type":"NoWork"}`))
+	})
+
+	b := NewRemotingBroker(client, "https://gocd.example.com", 5*time.Millisecond, func(Labels) *protocol.AgentRuntimeInfo {
+		return &protocol.AgentRuntimeInfo{}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	build, err := b.Pull(ctx, Labels{})
+	assert.Nil(t, build)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// TestRemotingBroker_Send_TranslatesReportActions ensures Send routes each report_* action to the
+// matching remoting endpoint, using the JobIdentifier Pull captured for the build.
+func TestRemotingBroker_Send_TranslatesReportActions(t *testing.T) {
+	var hitPaths []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hitPaths = append(hitPaths, r.URL.Path)
+		if r.URL.Path == "/remoting/api/agent/get_work" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(buildWorkJSON))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	b := NewRemotingBroker(client, "https://gocd.example.com", time.Hour, func(Labels) *protocol.AgentRuntimeInfo {
+		return &protocol.AgentRuntimeInfo{}
+	})
+	build, err := b.Pull(context.Background(), Labels{})
+	require.NoError(t, err)
+
+	report := &protocol.Report{BuildId: build.BuildId, JobState: "Building", AgentRuntimeInfo: &protocol.AgentRuntimeInfo{}}
+	b.Send(protocol.ReportCurrentStatusMessage(report))
+	b.Send(protocol.ReportCompletingMessage(report))
+	b.Send(protocol.ReportCompletedMessage(report))
+
+	assert.Contains(t, hitPaths, "/remoting/api/agent/report_current_status")
+	assert.Contains(t, hitPaths, "/remoting/api/agent/report_completing")
+	assert.Contains(t, hitPaths, "/remoting/api/agent/report_completed")
+}
+
+// TestRemotingBroker_Send_DropsMessageForUnknownBuild ensures Send is a no-op for a BuildId Pull
+// never returned (or already Acked/Nacked) - it must not panic on a nil JobIdentifier.
+func TestRemotingBroker_Send_DropsMessageForUnknownBuild(t *testing.T) {
+	called := false
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	b := NewRemotingBroker(client, "https://gocd.example.com", time.Hour, func(Labels) *protocol.AgentRuntimeInfo {
+		return &protocol.AgentRuntimeInfo{}
+	})
+
+	report := &protocol.Report{BuildId: "never-pulled", JobState: "Completed", Result: "Passed", AgentRuntimeInfo: &protocol.AgentRuntimeInfo{}}
+	b.Send(protocol.ReportCompletedMessage(report))
+
+	assert.False(t, called, "Send should not call the remoting client for a build it never Pulled")
+}
+
+// TestRemotingBroker_Ack_ForgetsJobIdentifier ensures a message for a build that's already been
+// Acked is dropped, the same as one that was never Pulled.
+func TestRemotingBroker_Ack_ForgetsJobIdentifier(t *testing.T) {
+	var hitPaths []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		hitPaths = append(hitPaths, r.URL.Path)
+		if r.URL.Path == "/remoting/api/agent/get_work" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(buildWorkJSON))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	b := NewRemotingBroker(client, "https://gocd.example.com", time.Hour, func(Labels) *protocol.AgentRuntimeInfo {
+		return &protocol.AgentRuntimeInfo{}
+	})
+	build, err := b.Pull(context.Background(), Labels{})
+	require.NoError(t, err)
+
+	b.Ack(build.BuildId)
+	hitPaths = nil
+
+	report := &protocol.Report{BuildId: build.BuildId, JobState: "Completed", Result: "Passed", AgentRuntimeInfo: &protocol.AgentRuntimeInfo{}}
+	b.Send(protocol.ReportCompletedMessage(report))
+
+	assert.Empty(t, hitPaths, "Send should not report for a build already Acked")
+}
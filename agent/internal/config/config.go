@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -19,27 +20,92 @@ import (
 type Config struct {
 	// Server connection
 	ServerURL *url.URL
-	
+
 	// Working directories
 	WorkingDir string
-	WorkDir   string
-	ConfigDir string
-	
+	WorkDir    string
+	ConfigDir  string
+
 	// Agent identity
 	Hostname  string
 	IPAddress string
 	UUID      string
-	
+
 	// Auto-registration
-	AutoRegisterKey         string
-	Resources               string
-	Environments            string
-	ElasticAgentID          string
-	ElasticPluginID         string
-	
+	AutoRegisterKey string
+	Resources       string
+	Environments    string
+	ElasticAgentID  string
+	ElasticPluginID string
+
 	// Polling intervals
 	HeartbeatInterval time.Duration
 	WorkPollInterval  time.Duration
+
+	// MaxConcurrentJobs bounds how many builds agent.WorkerPool runs at once on the remoting
+	// (poll-based) path; 1 (the default) matches a classic single-job GoCD agent.
+	MaxConcurrentJobs int
+
+	// DrainTimeout bounds how long the agent waits after SIGHUP for in-flight builds to finish on
+	// their own before canceling them (SIGINT/SIGTERM cancel at once), and how long it then waits
+	// for canceled builds to report - see shutdown.go's handleSignals/drain.
+	DrainTimeout time.Duration
+
+	// Executor backend: "local" (default) or "docker"; overridable per BuildCommand.
+	ExecutorBackend string
+
+	// DockerRegistryUsername/Password authenticate `docker login` before pulling an image for a
+	// "docker" backend step with attributes.pull set; empty means pull anonymously.
+	DockerRegistryUsername string
+	DockerRegistryPassword string
+
+	// Transport selects the channel used to exchange protocol.Message with the server: "ws"
+	// (default, the WebSocket JSON protocol), "grpc" (internal/remoting/grpc), or "remoting"
+	// (the classic poll-based get_work/ping API, run via a broker.RemotingBroker and
+	// agent.WorkerPool - see Agent.runRemoting).
+	Transport string
+
+	// PluginDir is where the agent looks for gocd-agent-plugin-<name> executor plugin
+	// binaries; empty means PluginDirOrDefault's ${ConfigDir}/plugins.
+	PluginDir string
+
+	// TaskPluginsDir is where the agent looks for gocd-task-plugin-<id> Pluggable Task plugin
+	// binaries (see agent/pkg/plugins); empty means TaskPluginsDirOrDefault's
+	// ${ConfigDir}/task-plugins. Distinct from PluginDir: these speak GoCD's own Plugin JSON
+	// message API v1, not this repo's simpler executor plugin protocol.
+	TaskPluginsDir string
+
+	// ExtraCAFiles is a comma-separated list of additional PEM CA bundle paths trusted
+	// alongside GoServerCAFile() - see ExtraCAFileList and internal/tlsconfig. Useful when the
+	// agent's outbound network path (a TLS-terminating proxy, a corporate MITM appliance) adds
+	// a CA the GoCD server itself doesn't know about.
+	ExtraCAFiles string
+
+	// LogFormat selects the agent's structured log output: "text" (default, human-readable) or
+	// "json" (for a log aggregator) - see internal/logging and the --log-format flag.
+	LogFormat string
+
+	// KillGrace bounds how long a cancelled BuildCommand's process group is given to exit after
+	// SIGTERM before agent.Agent escalates to SIGKILL - see agent.go's waitWithCancellation.
+	KillGrace time.Duration
+
+	// ArtifactStoreBackend selects where the Upload/Download executors persist artifacts:
+	// "local" (default, a directory on this host or a shared mount) or "s3" (an S3-compatible
+	// bucket) - see executor.NewArtifactStore.
+	ArtifactStoreBackend string
+
+	// ArtifactStoreDir is the root directory for the "local" artifact store backend; artifact
+	// keys are joined onto it.
+	ArtifactStoreDir string
+
+	// S3Bucket/S3Region/S3Endpoint/S3AccessKey/S3SecretKey configure the "s3" artifact store
+	// backend. S3Endpoint overrides the default AWS endpoint for S3-compatible services (e.g.
+	// MinIO); leave it empty to use AWS S3 itself.
+	S3Bucket    string
+	S3Region    string
+	S3Endpoint  string
+	S3AccessKey string
+	S3SecretKey string
 }
 
 // Load creates a Config from environment variables with sensible defaults
@@ -47,31 +113,49 @@ type Config struct {
 func Load() (*Config, error) {
 	// Setup viper with AGENT_ prefix for environment variables
 	setupViper()
-	
+
 	serverURLStr := viper.GetString("server.url")
 	serverURL, err := url.Parse(serverURLStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid server URL: %w", err)
 	}
-	
+
 	workDir := viper.GetString("work.dir")
-	
+
 	cfg := &Config{
-		ServerURL:         serverURL,
-		WorkDir:           workDir,
-		WorkingDir:        workDir,
-		HeartbeatInterval: viper.GetDuration("heartbeat.interval"),
-		WorkPollInterval:  viper.GetDuration("work.poll.interval"),
-		AutoRegisterKey:   viper.GetString("auto.register.key"),
-		Resources:         viper.GetString("auto.register.resources"),
-		Environments:      viper.GetString("auto.register.environments"),
-		ElasticAgentID:    viper.GetString("auto.register.elastic.agent.id"),
-		ElasticPluginID:   viper.GetString("auto.register.elastic.plugin.id"),
+		ServerURL:              serverURL,
+		WorkDir:                workDir,
+		WorkingDir:             workDir,
+		HeartbeatInterval:      viper.GetDuration("heartbeat.interval"),
+		WorkPollInterval:       viper.GetDuration("work.poll.interval"),
+		MaxConcurrentJobs:      viper.GetInt("max.concurrent.jobs"),
+		DrainTimeout:           viper.GetDuration("drain.timeout"),
+		AutoRegisterKey:        viper.GetString("auto.register.key"),
+		Resources:              viper.GetString("auto.register.resources"),
+		Environments:           viper.GetString("auto.register.environments"),
+		ElasticAgentID:         viper.GetString("auto.register.elastic.agent.id"),
+		ElasticPluginID:        viper.GetString("auto.register.elastic.plugin.id"),
+		ExecutorBackend:        viper.GetString("executor.backend"),
+		PluginDir:              viper.GetString("plugin.dir"),
+		TaskPluginsDir:         viper.GetString("task.plugins.dir"),
+		ExtraCAFiles:           viper.GetString("extra.ca.files"),
+		Transport:              viper.GetString("transport"),
+		DockerRegistryUsername: viper.GetString("docker.registry.username"),
+		DockerRegistryPassword: viper.GetString("docker.registry.password"),
+		LogFormat:              viper.GetString("log.format"),
+		KillGrace:              viper.GetDuration("kill.grace"),
+		ArtifactStoreBackend:   viper.GetString("artifact.store.backend"),
+		ArtifactStoreDir:       viper.GetString("artifact.store.dir"),
+		S3Bucket:               viper.GetString("s3.bucket"),
+		S3Region:               viper.GetString("s3.region"),
+		S3Endpoint:             viper.GetString("s3.endpoint"),
+		S3AccessKey:            viper.GetString("s3.access.key"),
+		S3SecretKey:            viper.GetString("s3.secret.key"),
 	}
-	
+
 	// Derive ConfigDir from WorkDir
 	cfg.ConfigDir = filepath.Join(cfg.WorkDir, "config")
-	
+
 	// Ensure directories exist
 	if err := os.MkdirAll(cfg.WorkDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create work directory: %w", err)
@@ -79,18 +163,18 @@ func Load() (*Config, error) {
 	if err := os.MkdirAll(cfg.ConfigDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
-	
+
 	// Detect hostname and IP
 	cfg.Hostname, err = os.Hostname()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get hostname: %w", err)
 	}
-	
+
 	cfg.IPAddress, err = detectIPAddress()
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect IP address: %w", err)
 	}
-	
+
 	return cfg, nil
 }
 
@@ -98,25 +182,111 @@ func Load() (*Config, error) {
 func setupViper() {
 	// Set environment variable prefix (AGENT_)
 	viper.SetEnvPrefix("AGENT")
-	
+
 	// Replace dots and dashes with underscores in env var names
 	// e.g., "server.url" becomes "AGENT_SERVER_URL"
 	replacer := strings.NewReplacer(".", "_", "-", "_")
 	viper.SetEnvKeyReplacer(replacer)
-	
+
 	// Automatically read environment variables
 	viper.AutomaticEnv()
-	
+
 	// Set default values following 12-factor app principles
 	viper.SetDefault("server.url", "http://localhost:8153/go")
 	viper.SetDefault("work.dir", "./work")
 	viper.SetDefault("heartbeat.interval", 10*time.Second)
 	viper.SetDefault("work.poll.interval", 5*time.Second)
+	viper.SetDefault("max.concurrent.jobs", 1)
+	viper.SetDefault("drain.timeout", 2*time.Minute)
 	viper.SetDefault("auto.register.key", "")
 	viper.SetDefault("auto.register.resources", "")
 	viper.SetDefault("auto.register.environments", "")
 	viper.SetDefault("auto.register.elastic.agent.id", "")
 	viper.SetDefault("auto.register.elastic.plugin.id", "")
+	viper.SetDefault("executor.backend", "local")
+	viper.SetDefault("plugin.dir", "")
+	viper.SetDefault("task.plugins.dir", "")
+	viper.SetDefault("extra.ca.files", "")
+	viper.SetDefault("transport", "ws")
+	viper.SetDefault("docker.registry.username", "")
+	viper.SetDefault("docker.registry.password", "")
+	viper.SetDefault("log.format", "text")
+	viper.SetDefault("kill.grace", 10*time.Second)
+	viper.SetDefault("artifact.store.backend", "local")
+	viper.SetDefault("artifact.store.dir", "./artifacts")
+	viper.SetDefault("s3.bucket", "")
+	viper.SetDefault("s3.region", "")
+	viper.SetDefault("s3.endpoint", "")
+	viper.SetDefault("s3.access.key", "")
+	viper.SetDefault("s3.secret.key", "")
+}
+
+// UsesGRPCTransport reports whether AGENT_TRANSPORT selects the gRPC transport instead of the
+// default WebSocket one.
+func (c *Config) UsesGRPCTransport() bool {
+	return c.Transport == "grpc"
+}
+
+// UsesRemotingTransport reports whether AGENT_TRANSPORT selects the classic, poll-based GoCD
+// remoting API (get_work/ping) instead of a persistent WebSocket/gRPC connection - see
+// agent.Agent.runRemoting, which runs a broker.RemotingBroker and agent.WorkerPool against it so
+// up to MaxConcurrentJobs builds run at once.
+func (c *Config) UsesRemotingTransport() bool {
+	return c.Transport == "remoting"
+}
+
+// PluginDirOrDefault returns PluginDir, or ${ConfigDir}/plugins if it wasn't set
+// (AGENT_PLUGIN_DIR).
+func (c *Config) PluginDirOrDefault() string {
+	if c.PluginDir != "" {
+		return c.PluginDir
+	}
+	return filepath.Join(c.ConfigDir, "plugins")
+}
+
+// TaskPluginsDirOrDefault returns TaskPluginsDir, or ${ConfigDir}/task-plugins if it wasn't set
+// (AGENT_TASK_PLUGINS_DIR).
+func (c *Config) TaskPluginsDirOrDefault() string {
+	if c.TaskPluginsDir != "" {
+		return c.TaskPluginsDir
+	}
+	return filepath.Join(c.ConfigDir, "task-plugins")
+}
+
+// ResourceList splits Resources (AGENT_AUTO_REGISTER_RESOURCES) into its comma-separated
+// entries and appends an implicit "platform:GOOS/GOARCH" label, so the server can route
+// OS/arch-specific jobs (e.g. "platform:windows/amd64") without an explicit admin resource.
+// Reported in AgentRuntimeInfo and checked against a Build's RequiredResources/Labels by
+// Agent.matchesBuild.
+func (c *Config) ResourceList() []string {
+	resources := splitCSV(c.Resources)
+	return append(resources, fmt.Sprintf("platform:%s/%s", runtime.GOOS, runtime.GOARCH))
+}
+
+// EnvironmentList splits Environments (AGENT_AUTO_REGISTER_ENVIRONMENTS) into its
+// comma-separated entries.
+func (c *Config) EnvironmentList() []string {
+	return splitCSV(c.Environments)
+}
+
+// ExtraCAFileList splits ExtraCAFiles (AGENT_EXTRA_CA_FILES) into its comma-separated entries.
+func (c *Config) ExtraCAFileList() []string {
+	return splitCSV(c.ExtraCAFiles)
+}
+
+// splitCSV splits s on commas, trims whitespace, and drops empty entries.
+func splitCSV(s string) []string {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
 }
 
 // UUIDFile returns path to the agent UUID file
@@ -151,6 +321,20 @@ func (c *Config) WebSocketURL() string {
 	return u.String()
 }
 
+// RemotingBaseURL returns the root of the classic GoCD remoting API (get_work/get_cookie/ping),
+// used by remoting.Client - see WebSocketURL for the newer WebSocket protocol's equivalent.
+func (c *Config) RemotingBaseURL() string {
+	u := *c.ServerURL
+	u.Path = filepath.Join(u.Path, "remoting/api/agent")
+	return u.String()
+}
+
+// GRPCAddress returns the host:port the gRPC transport dials, derived from ServerURL's host
+// (the gRPC transport is a raw streaming channel, not a sub-path of the GoCD HTTP API).
+func (c *Config) GRPCAddress() string {
+	return c.ServerURL.Host
+}
+
 // GoServerCAFile returns path to server CA certificate
 func (c *Config) GoServerCAFile() string {
 	return filepath.Join(c.ConfigDir, "go-server-ca.pem")
@@ -177,7 +361,7 @@ func detectIPAddress() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	
+
 	for _, addr := range addrs {
 		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
 			if ipnet.IP.To4() != nil {
@@ -185,6 +369,6 @@ func detectIPAddress() (string, error) {
 			}
 		}
 	}
-	
+
 	return "127.0.0.1", nil
 }
@@ -0,0 +1,190 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Structured console log batching and replay, inspired by cncd/logging: lines accumulate in
+// an in-memory ring buffer per build and are flushed to the server in small batches instead of
+// one WebSocket message (or HTTP POST) per line.
+
+package console
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+const (
+	batchFlushInterval    = 200 * time.Millisecond
+	batchMaxLines         = 50
+	defaultMaxBufferBytes = 1 << 20 // 1MiB of history, overridable via AGENT_CONSOLE_BUFFER_BYTES
+)
+
+// ReplaySource is implemented by anything that can hand a build's full console history to a
+// late subscriber - e.g. the server-side tail reconnecting mid-build. Batcher implements it.
+type ReplaySource interface {
+	History() []protocol.LogLine
+}
+
+// Batcher accumulates protocol.LogLine for one build, flushing them to send in batches - every
+// batchFlushInterval or every batchMaxLines lines, whichever comes first - while retaining the
+// full ordered history in memory (bounded by maxBufferBytes, oldest dropped first) so it can be
+// replayed to late subscribers and assembled into the final console log at build completion.
+type Batcher struct {
+	buildID        string
+	send           func(*protocol.Message)
+	maxBufferBytes int
+
+	lineCh  chan protocol.LogLine
+	stop    chan struct{}
+	stopped chan struct{}
+
+	mu           sync.Mutex
+	pending      []protocol.LogLine
+	history      []protocol.LogLine
+	historyBytes int
+}
+
+var _ ReplaySource = (*Batcher)(nil)
+
+// NewBatcher creates a Batcher for buildID that flushes batches via send. maxBufferBytes
+// bounds the in-memory replay history (AGENT_CONSOLE_BUFFER_BYTES); 0 uses the default.
+func NewBatcher(buildID string, send func(*protocol.Message), maxBufferBytes int) *Batcher {
+	if maxBufferBytes <= 0 {
+		maxBufferBytes = defaultMaxBufferBytes
+	}
+	b := &Batcher{
+		buildID:        buildID,
+		send:           send,
+		maxBufferBytes: maxBufferBytes,
+		lineCh:         make(chan protocol.LogLine, 256),
+		stop:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+	go b.loop()
+	return b
+}
+
+// MaxBufferBytesFromEnv reads AGENT_CONSOLE_BUFFER_BYTES, returning 0 (the default) if unset
+// or invalid.
+func MaxBufferBytesFromEnv() int {
+	v := os.Getenv("AGENT_CONSOLE_BUFFER_BYTES")
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n
+}
+
+// Append queues line for batching. line.BuildId is filled in if empty.
+func (b *Batcher) Append(line protocol.LogLine) {
+	if line.BuildId == "" {
+		line.BuildId = b.buildID
+	}
+	select {
+	case b.lineCh <- line:
+	case <-b.stop:
+	}
+}
+
+func (b *Batcher) loop() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(batchFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			b.drainPending()
+			b.flush()
+			return
+		case line := <-b.lineCh:
+			b.buffer(line)
+			if b.pendingLen() >= batchMaxLines {
+				b.flush()
+			}
+		case <-ticker.C:
+			b.flush()
+		}
+	}
+}
+
+// drainPending buffers any lines still queued in lineCh after stop is closed, so Close doesn't
+// lose lines that were Append-ed just before it.
+func (b *Batcher) drainPending() {
+	for {
+		select {
+		case line := <-b.lineCh:
+			b.buffer(line)
+		default:
+			return
+		}
+	}
+}
+
+func (b *Batcher) buffer(line protocol.LogLine) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = append(b.pending, line)
+	b.history = append(b.history, line)
+	b.historyBytes += len(line.Msg)
+	for b.historyBytes > b.maxBufferBytes && len(b.history) > 0 {
+		b.historyBytes -= len(b.history[0].Msg)
+		b.history = b.history[1:]
+	}
+}
+
+func (b *Batcher) pendingLen() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+func (b *Batcher) flush() {
+	b.mu.Lock()
+	if len(b.pending) == 0 {
+		b.mu.Unlock()
+		return
+	}
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if b.send != nil {
+		b.send(protocol.AppendConsoleLogMessage(batch))
+	}
+}
+
+// Close stops the batcher, flushing any pending lines first.
+func (b *Batcher) Close() error {
+	close(b.stop)
+	<-b.stopped
+	return nil
+}
+
+// History returns a copy of the full ordered in-memory log history, for replay to a late
+// subscriber.
+func (b *Batcher) History() []protocol.LogLine {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]protocol.LogLine, len(b.history))
+	copy(out, b.history)
+	return out
+}
+
+// AssembledText renders the full history back into plain text (one Msg per line,
+// newline-joined) - used to build the final console log uploaded to ConsoleUrl at build
+// completion, so that upload doesn't depend on having kept a separate raw-byte copy around.
+func (b *Batcher) AssembledText() string {
+	lines := b.History()
+	var sb strings.Builder
+	for _, l := range lines {
+		sb.WriteString(l.Msg)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
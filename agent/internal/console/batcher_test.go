@@ -0,0 +1,101 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package console
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func TestBatcher_FlushesOnClose(t *testing.T) {
+	var mu sync.Mutex
+	var sent []protocol.LogLine
+	send := func(m *protocol.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, m.LogLines()...)
+	}
+
+	b := NewBatcher("build-1", send, 0)
+	b.Append(protocol.LogLine{Msg: "hello"})
+	b.Append(protocol.LogLine{Msg: "world"})
+	b.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 2 {
+		t.Fatalf("got %d lines sent, want 2: %+v", len(sent), sent)
+	}
+	if sent[0].BuildId != "build-1" {
+		t.Errorf("BuildId = %q, want build-1", sent[0].BuildId)
+	}
+}
+
+func TestBatcher_FlushesAtMaxLines(t *testing.T) {
+	var mu sync.Mutex
+	flushes := 0
+	send := func(m *protocol.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		flushes++
+	}
+
+	b := NewBatcher("build-1", send, 0)
+	for i := 0; i < batchMaxLines; i++ {
+		b.Append(protocol.LogLine{Msg: "line"})
+	}
+	b.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if flushes == 0 {
+		t.Error("expected at least one flush once batchMaxLines was reached")
+	}
+}
+
+func TestBatcher_HistoryAndAssembledText(t *testing.T) {
+	b := NewBatcher("build-1", func(*protocol.Message) {}, 0)
+	b.Append(protocol.LogLine{Msg: "hello"})
+	b.Append(protocol.LogLine{Msg: "world"})
+	b.Close()
+
+	history := b.History()
+	if len(history) != 2 {
+		t.Fatalf("got %d history entries, want 2", len(history))
+	}
+	if got, want := b.AssembledText(), "hello\nworld\n"; got != want {
+		t.Errorf("AssembledText() = %q, want %q", got, want)
+	}
+}
+
+func TestBatcher_DropsOldestHistoryBeyondMaxBufferBytes(t *testing.T) {
+	b := NewBatcher("build-1", func(*protocol.Message) {}, 5)
+	b.Append(protocol.LogLine{Msg: "aaaaa"})
+	b.Append(protocol.LogLine{Msg: "bbbbb"})
+	b.Close()
+
+	history := b.History()
+	if len(history) != 1 || history[0].Msg != "bbbbb" {
+		t.Errorf("expected oldest line dropped, got %+v", history)
+	}
+}
+
+func TestMaxBufferBytesFromEnv(t *testing.T) {
+	t.Setenv("AGENT_CONSOLE_BUFFER_BYTES", "")
+	if got := MaxBufferBytesFromEnv(); got != 0 {
+		t.Errorf("unset env: got %d, want 0", got)
+	}
+
+	t.Setenv("AGENT_CONSOLE_BUFFER_BYTES", "2048")
+	if got := MaxBufferBytesFromEnv(); got != 2048 {
+		t.Errorf("got %d, want 2048", got)
+	}
+
+	t.Setenv("AGENT_CONSOLE_BUFFER_BYTES", "not-a-number")
+	if got := MaxBufferBytesFromEnv(); got != 0 {
+		t.Errorf("invalid env: got %d, want 0", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package console
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// LineWriter implements io.Writer, splitting arbitrary writes on newlines into complete lines
+// and handing each to sink as a protocol.LogLine tagged with the current Proc (via procFn, so
+// one writer instance can be reused for a whole build as the running command changes) and a
+// monotonically increasing Pos. A trailing partial line (no newline yet) is buffered until the
+// next Write, or emitted by Flush.
+type LineWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	pos    int
+	procFn func() string
+	sink   func(protocol.LogLine)
+}
+
+// NewLineWriter creates a LineWriter that tags each emitted line with procFn() and passes it
+// to sink.
+func NewLineWriter(procFn func() string, sink func(protocol.LogLine)) *LineWriter {
+	return &LineWriter{procFn: procFn, sink: sink}
+}
+
+// Write implements io.Writer.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	for {
+		data := w.buf.Bytes()
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		w.emitLocked(strings.TrimSuffix(string(data[:i]), "\r"))
+		w.buf.Next(i + 1)
+	}
+	return len(p), nil
+}
+
+// Flush emits any buffered partial line (no trailing newline) as a final line. Call once when
+// a command finishes, so its last unterminated line of output isn't silently dropped.
+func (w *LineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() == 0 {
+		return
+	}
+	w.emitLocked(w.buf.String())
+	w.buf.Reset()
+}
+
+func (w *LineWriter) emitLocked(msg string) {
+	w.pos++
+	w.sink(protocol.LogLine{
+		Proc: w.procFn(),
+		Time: time.Now().UnixMilli(),
+		Pos:  w.pos,
+		Out:  protocol.LogLineStdout,
+		Msg:  msg,
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package console
+
+import (
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func TestLineWriter_SplitsOnNewlines(t *testing.T) {
+	var lines []protocol.LogLine
+	w := NewLineWriter(func() string { return "task1" }, func(l protocol.LogLine) {
+		lines = append(lines, l)
+	})
+
+	if _, err := w.Write([]byte("hello\nworld\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if lines[0].Msg != "hello" || lines[1].Msg != "world" {
+		t.Errorf("unexpected lines: %+v", lines)
+	}
+	if lines[0].Pos != 1 || lines[1].Pos != 2 {
+		t.Errorf("unexpected Pos: %+v", lines)
+	}
+	if lines[0].Proc != "task1" {
+		t.Errorf("Proc = %q, want task1", lines[0].Proc)
+	}
+	if lines[0].Out != protocol.LogLineStdout {
+		t.Errorf("Out = %q, want %q", lines[0].Out, protocol.LogLineStdout)
+	}
+}
+
+func TestLineWriter_BuffersPartialLineUntilFlush(t *testing.T) {
+	var lines []protocol.LogLine
+	w := NewLineWriter(func() string { return "task1" }, func(l protocol.LogLine) {
+		lines = append(lines, l)
+	})
+
+	w.Write([]byte("partial"))
+	if len(lines) != 0 {
+		t.Fatalf("partial line emitted early: %+v", lines)
+	}
+
+	w.Flush()
+	if len(lines) != 1 || lines[0].Msg != "partial" {
+		t.Errorf("Flush should emit buffered partial line, got %+v", lines)
+	}
+
+	w.Flush()
+	if len(lines) != 1 {
+		t.Errorf("Flush with no buffered data should not emit, got %+v", lines)
+	}
+}
+
+func TestLineWriter_TrimsTrailingCR(t *testing.T) {
+	var lines []protocol.LogLine
+	w := NewLineWriter(func() string { return "task1" }, func(l protocol.LogLine) {
+		lines = append(lines, l)
+	})
+
+	w.Write([]byte("crlf\r\n"))
+	if len(lines) != 1 || lines[0].Msg != "crlf" {
+		t.Errorf("got %+v, want single line %q", lines, "crlf")
+	}
+}
+
+func TestLineWriter_ProcChangesMidStream(t *testing.T) {
+	proc := "task1"
+	var lines []protocol.LogLine
+	w := NewLineWriter(func() string { return proc }, func(l protocol.LogLine) {
+		lines = append(lines, l)
+	})
+
+	w.Write([]byte("first\n"))
+	proc = "task2"
+	w.Write([]byte("second\n"))
+
+	if lines[0].Proc != "task1" || lines[1].Proc != "task2" {
+		t.Errorf("unexpected proc tags: %+v", lines)
+	}
+}
@@ -1,55 +1,161 @@
 // Copyright © 2026 ex_gocd
 // Licensed under the Apache License, Version 2.0
-// Buffered console log writer: timestamp prefix (HH:mm:ss.SSS), periodic HTTP POST to server.
-
+// Buffered console log writer: timestamp prefix (HH:mm:ss.SSS), gzip-compressed batched HTTP
+// POST to server with retry/backoff, and a bounded in-memory buffer so a chatty build can't
+// grow the agent's memory without limit. NewDurableWriter additionally persists every line to
+// a logstream.Stream ring before it's queued, and resumes unacked lines on construction, so a
+// hiccup that loses the in-flight batch (or an agent restart mid-build) doesn't lose output -
+// see logstream.
 package console
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/backoff"
+	"github.com/d-led/ex_gocd/agent/internal/logstream"
 )
 
 const (
-	flushInterval = 5 * time.Second
-	timeFormat    = "15:04:05.000"
+	flushInterval          = 5 * time.Second
+	timeFormat             = "15:04:05.000"
+	defaultWriterMaxBytes  = 1 << 20 // 1MiB of unflushed output, overridable via AGENT_CONSOLE_WRITER_BUFFER_BYTES
+	maxFlushAttempts       = 5       // 5 POSTs, 2s, 4s, 8s, 16s apart - the agent reconnect loop's backoff
+	truncatedMarkerPattern = "...%d line(s) truncated...\n"
 )
 
-// Writer buffers output, prefixes each line with timestamp, and flushes to server via HTTP POST.
+// writerBackoff matches the agent's WebSocket reconnect loop (Agent.Start): 2s base, capped at
+// 60s, no jitter - a flush retries on the same schedule a reconnect would.
+var writerBackoff = backoff.Strategy{Base: 2 * time.Second, Max: 60 * time.Second, Jitter: backoff.NoJitter}
+
+// Writer buffers output, prefixes each write with a timestamp, and flushes to server via a
+// gzip-compressed HTTP POST, retrying transient failures with backoff. Writes queue in
+// memory up to maxBufferBytes; once exceeded, the oldest queued writes are dropped and replaced
+// with a "...N line(s) truncated..." marker so a chatty build can't grow the agent's memory
+// without limit, and the server-side log says so rather than silently losing output.
 type Writer struct {
-	mu       sync.Mutex
-	buf      bytes.Buffer
-	client   *http.Client
-	postURL  string // Resolved full URL for POST
+	client  *http.Client
+	postURL string // Resolved full URL for POST
+
+	maxBufferBytes int
+
+	// stream durably persists every buffered line (see NewDurableWriter); nil for a plain
+	// NewWriter, which behaves exactly as before - in-memory buffering only.
+	stream      *logstream.Stream
+	lastAckedID int64
+
+	mu         sync.Mutex
+	pending    [][]byte
+	pendingIDs []int64 // parallel to pending; stream.Append's ID for each line, or 0 if stream is nil
+	pendingSz  int
+	truncated  int
+
 	stop     chan struct{}
 	stopped  chan struct{}
 	writeCh  chan []byte
+	flushReq chan chan struct{}
 	prefixFn func() []byte
 }
 
-// NewWriter creates a console writer that POSTs to consoleURL (resolved against baseURL if relative).
-func NewWriter(client *http.Client, baseURL *url.URL, consoleURL string) (*Writer, error) {
-	postURL, err := resolveURL(baseURL, consoleURL)
+// NewWriter creates a console writer that POSTs to consoleURL (resolved against baseURL if
+// relative). maxBufferBytes bounds the in-memory queue of unflushed writes
+// (AGENT_CONSOLE_WRITER_BUFFER_BYTES); 0 uses the default.
+func NewWriter(client *http.Client, baseURL *url.URL, consoleURL string, maxBufferBytes int) (*Writer, error) {
+	w, err := newWriter(client, baseURL, consoleURL, maxBufferBytes)
 	if err != nil {
 		return nil, err
 	}
-	w := &Writer{
-		client:   client,
-		postURL:  postURL,
-		stop:     make(chan struct{}),
-		stopped:  make(chan struct{}),
-		writeCh:  make(chan []byte, 64),
-		prefixFn: timestampPrefix,
+	go w.flushLoop()
+	return w, nil
+}
+
+// NewDurableWriter is NewWriter, backed by a logstream.Stream ring under
+// workingDir/logs/buildLocator.ndjson: every buffered line is persisted before it's queued,
+// each flush's POST carries "?after=<id>" (the line immediately preceding the batch) so a
+// receiver with replay support knows the offset it resumes from, and any line the ring holds
+// past the last acked ID - e.g. the agent restarted mid-build, before that line's batch was
+// ever POSTed - is requeued on construction instead of lost.
+func NewDurableWriter(client *http.Client, baseURL *url.URL, consoleURL, workingDir, buildLocator string, maxBufferBytes int) (*Writer, error) {
+	w, err := newWriter(client, baseURL, consoleURL, maxBufferBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := logstream.Open(workingDir, buildLocator)
+	if err != nil {
+		return nil, fmt.Errorf("console writer: %w", err)
+	}
+	w.stream = stream
+
+	lastAcked, err := logstream.ReadAck(stream.AckPath())
+	if err != nil {
+		return nil, fmt.Errorf("console writer: reading ack: %w", err)
+	}
+	w.lastAckedID = lastAcked
+
+	unacked, err := stream.Since(lastAcked)
+	if err != nil {
+		return nil, fmt.Errorf("console writer: replaying unacked lines: %w", err)
 	}
+	if len(unacked) > 0 {
+		w.mu.Lock()
+		for _, l := range unacked {
+			w.pending = append(w.pending, l.Data)
+			w.pendingIDs = append(w.pendingIDs, l.ID)
+			w.pendingSz += len(l.Data)
+		}
+		w.mu.Unlock()
+	}
+
 	go w.flushLoop()
 	return w, nil
 }
 
+func newWriter(client *http.Client, baseURL *url.URL, consoleURL string, maxBufferBytes int) (*Writer, error) {
+	postURL, err := resolveURL(baseURL, consoleURL)
+	if err != nil {
+		return nil, err
+	}
+	if maxBufferBytes <= 0 {
+		maxBufferBytes = defaultWriterMaxBytes
+	}
+	return &Writer{
+		client:         client,
+		postURL:        postURL,
+		maxBufferBytes: maxBufferBytes,
+		stop:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+		writeCh:        make(chan []byte, 64),
+		flushReq:       make(chan chan struct{}),
+		prefixFn:       timestampPrefix,
+	}, nil
+}
+
+// WriterMaxBufferBytesFromEnv reads AGENT_CONSOLE_WRITER_BUFFER_BYTES, returning 0 (the default)
+// if unset or invalid.
+func WriterMaxBufferBytesFromEnv() int {
+	v := os.Getenv("AGENT_CONSOLE_WRITER_BUFFER_BYTES")
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n
+}
+
 func timestampPrefix() []byte {
 	return []byte(time.Now().Format(timeFormat) + " ")
 }
@@ -69,7 +175,8 @@ func resolveURL(base *url.URL, consoleURL string) (string, error) {
 	return u.String(), nil
 }
 
-// Write implements io.Writer. Each line is prefixed with timestamp before buffering.
+// Write implements io.Writer. Each write is queued, then prefixed with a timestamp when the flush
+// loop buffers it.
 func (w *Writer) Write(p []byte) (n int, err error) {
 	if len(p) == 0 {
 		return 0, nil
@@ -85,10 +192,25 @@ func (w *Writer) Write(p []byte) (n int, err error) {
 	}
 }
 
-// Close stops the flush loop and flushes remaining data.
+// Flush blocks until all writes queued so far have been POSTed (including retries). Callers
+// that need to guarantee delivery before acting on it - e.g. handleBuild reporting "Completed"
+// only after the console log is uploaded - should call Flush before proceeding.
+func (w *Writer) Flush() {
+	done := make(chan struct{})
+	select {
+	case w.flushReq <- done:
+		<-done
+	case <-w.stop:
+	}
+}
+
+// Close stops the flush loop, flushing (with retries) any remaining data first.
 func (w *Writer) Close() error {
 	close(w.stop)
 	<-w.stopped
+	if w.stream != nil {
+		return w.stream.Close()
+	}
 	return nil
 }
 
@@ -99,45 +221,187 @@ func (w *Writer) flushLoop() {
 	for {
 		select {
 		case <-w.stop:
+			w.drainPending()
 			w.flush()
 			return
 		case data, ok := <-w.writeCh:
 			if !ok {
 				return
 			}
-			w.mu.Lock()
-			w.buf.Write(w.prefixFn())
-			w.buf.Write(data)
-			if !bytes.HasSuffix(data, []byte("\n")) {
-				w.buf.WriteByte('\n')
-			}
-			w.mu.Unlock()
+			w.buffer(data)
+		case req := <-w.flushReq:
+			w.drainPending()
+			w.flush()
+			close(req)
 		case <-tick.C:
 			w.flush()
 		}
 	}
 }
 
+// drainPending buffers any writes still queued in writeCh, so Close/Flush don't lose writes
+// that landed just before them.
+func (w *Writer) drainPending() {
+	for {
+		select {
+		case data := <-w.writeCh:
+			w.buffer(data)
+		default:
+			return
+		}
+	}
+}
+
+// buffer appends a prefixed write to the pending queue, dropping the oldest queued writes if
+// maxBufferBytes is exceeded. If the writer is durable (see NewDurableWriter), the line is
+// persisted to the logstream.Stream first, so it survives even if it's later dropped from the
+// in-memory queue.
+func (w *Writer) buffer(data []byte) {
+	line := append(w.prefixFn(), data...)
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		line = append(line, '\n')
+	}
+
+	var id int64
+	if w.stream != nil {
+		l, err := w.stream.Append(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "console writer: failed to persist line to logstream: %v\n", err)
+		} else {
+			id = l.ID
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, line)
+	w.pendingIDs = append(w.pendingIDs, id)
+	w.pendingSz += len(line)
+	for w.pendingSz > w.maxBufferBytes && len(w.pending) > 0 {
+		w.pendingSz -= len(w.pending[0])
+		w.pending = w.pending[1:]
+		w.pendingIDs = w.pendingIDs[1:]
+		w.truncated++
+	}
+}
+
 func (w *Writer) flush() {
 	w.mu.Lock()
-	if w.buf.Len() == 0 {
+	if len(w.pending) == 0 {
 		w.mu.Unlock()
 		return
 	}
-	data := w.buf.Bytes()
-	body := make([]byte, len(data))
-	copy(body, data)
-	w.buf.Reset()
+	batch := w.pending
+	batchIDs := w.pendingIDs
+	truncated := w.truncated
+	w.pending = nil
+	w.pendingIDs = nil
+	w.pendingSz = 0
+	w.truncated = 0
 	w.mu.Unlock()
 
-	req, err := http.NewRequest(http.MethodPost, w.postURL, bytes.NewReader(body))
+	body, err := gzipBatch(batch, truncated)
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "console writer: failed to compress batch: %v\n", err)
 		return
 	}
+
+	postURL := w.batchPostURL(batchIDs)
+
+	attempt := 0
+	err = writerBackoff.Do(context.Background(), func() error {
+		attempt++
+		return w.post(postURL, body)
+	}, func(err error) bool {
+		var perm *permanentPostError
+		return attempt < maxFlushAttempts && !errors.As(err, &perm)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "console writer: giving up POSTing %d byte(s) of console output after %d attempt(s): %v\n", len(body), attempt, err)
+		return
+	}
+	w.ackBatch(batchIDs)
+}
+
+// batchPostURL appends "?after=<id>" (the line immediately preceding this batch) when the
+// writer is durable (see NewDurableWriter), so a receiver with replay support knows the offset
+// this batch resumes from.
+func (w *Writer) batchPostURL(batchIDs []int64) string {
+	if w.stream == nil || len(batchIDs) == 0 || batchIDs[0] <= 0 {
+		return w.postURL
+	}
+	sep := "?"
+	if strings.Contains(w.postURL, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%safter=%d", w.postURL, sep, batchIDs[0]-1)
+}
+
+// ackBatch records the highest line ID in a successfully POSTed batch, so a NewDurableWriter
+// created after a restart (see its ring replay) resumes from there instead of re-sending
+// already-delivered lines.
+func (w *Writer) ackBatch(batchIDs []int64) {
+	if w.stream == nil {
+		return
+	}
+	var highest int64
+	for _, id := range batchIDs {
+		if id > highest {
+			highest = id
+		}
+	}
+	if highest == 0 {
+		return
+	}
+	if err := logstream.WriteAck(w.stream.AckPath(), highest); err != nil {
+		fmt.Fprintf(os.Stderr, "console writer: failed to persist ack: %v\n", err)
+	}
+}
+
+// permanentPostError marks a POST failure retrying won't fix (a 4xx response), so flush's
+// backoff.Strategy.Do gives up immediately instead of burning through maxFlushAttempts.
+type permanentPostError struct{ err error }
+
+func (e *permanentPostError) Error() string { return e.err.Error() }
+func (e *permanentPostError) Unwrap() error { return e.err }
+
+func gzipBatch(lines [][]byte, truncated int) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if truncated > 0 {
+		if _, err := fmt.Fprintf(gz, truncatedMarkerPattern, truncated); err != nil {
+			return nil, err
+		}
+	}
+	for _, line := range lines {
+		if _, err := gz.Write(line); err != nil {
+			return nil, err
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *Writer) post(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
 	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Content-Encoding", "gzip")
 	resp, err := w.client.Do(req)
 	if err != nil {
-		return
+		return err
 	}
-	resp.Body.Close()
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("console POST %s: %s", url, resp.Status)
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		// 4xx: the request itself is malformed/rejected - retrying won't help.
+		return &permanentPostError{fmt.Errorf("console POST %s: %s", url, resp.Status)}
+	}
+	return nil
 }
@@ -0,0 +1,237 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package console
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func ungzip(t *testing.T, body []byte) string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	defer gz.Close()
+	out, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("read gzip: %v", err)
+	}
+	return string(out)
+}
+
+func TestWriter_FlushPostsGzippedBatch(t *testing.T) {
+	var mu sync.Mutex
+	var gotContentEncoding, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		gotContentEncoding = r.Header.Get("Content-Encoding")
+		gotBody = ungzip(t, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	base, _ := url.Parse(srv.URL)
+	w, err := NewWriter(http.DefaultClient, base, srv.URL+"/console", 0)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("hello"))
+	w.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotContentEncoding != "gzip" {
+		t.Errorf("Content-Encoding = %q, want gzip", gotContentEncoding)
+	}
+	if !bytes.HasSuffix([]byte(gotBody), []byte("hello\n")) {
+		t.Errorf("body = %q, want it to end with a timestamp-prefixed %q", gotBody, "hello\n")
+	}
+}
+
+func TestWriter_BufferDropsOldestBeyondMaxBufferBytes(t *testing.T) {
+	w := &Writer{maxBufferBytes: 6, prefixFn: func() []byte { return nil }}
+
+	w.buffer([]byte("aaaaa"))
+	w.buffer([]byte("bbbbb"))
+
+	if len(w.pending) != 1 || string(w.pending[0]) != "bbbbb\n" {
+		t.Fatalf("expected only the newest entry kept, got %q", w.pending)
+	}
+	if w.truncated != 1 {
+		t.Errorf("truncated = %d, want 1", w.truncated)
+	}
+}
+
+func TestWriter_GivesUpAfterMaxFlushAttemptsOn5xx(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	orig := writerBackoff
+	writerBackoff.Base, writerBackoff.Max = time.Millisecond, time.Millisecond
+	defer func() { writerBackoff = orig }()
+
+	base, _ := url.Parse(srv.URL)
+	w, err := NewWriter(http.DefaultClient, base, srv.URL+"/console", 0)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	w.Write([]byte("hello"))
+	w.Flush()
+	w.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != maxFlushAttempts {
+		t.Errorf("attempts = %d, want %d", attempts, maxFlushAttempts)
+	}
+}
+
+func TestWriter_DoesNotRetryOn4xx(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	base, _ := url.Parse(srv.URL)
+	w, err := NewWriter(http.DefaultClient, base, srv.URL+"/console", 0)
+	if err != nil {
+		t.Fatalf("NewWriter: %v", err)
+	}
+
+	w.Write([]byte("hello"))
+	w.Flush()
+	w.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (4xx is permanent, no retry)", attempts)
+	}
+}
+
+func TestNewDurableWriter_FlushCarriesAfterQueryParam(t *testing.T) {
+	var mu sync.Mutex
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		gotQuery = r.URL.RawQuery
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	base, _ := url.Parse(srv.URL)
+	w, err := NewDurableWriter(http.DefaultClient, base, srv.URL+"/console", t.TempDir(), "p/1/s/1/build-1", 0)
+	if err != nil {
+		t.Fatalf("NewDurableWriter: %v", err)
+	}
+	defer w.Close()
+
+	w.Write([]byte("first"))
+	w.Flush()
+	w.Write([]byte("second"))
+	w.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := "after=1"; gotQuery != want {
+		t.Errorf("second flush query = %q, want %q", gotQuery, want)
+	}
+}
+
+func TestNewDurableWriter_ResumesUnackedLinesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	// First writer: server always fails, so its line is persisted but never acked.
+	downSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	orig := writerBackoff
+	writerBackoff.Base, writerBackoff.Max = time.Millisecond, time.Millisecond
+	defer func() { writerBackoff = orig }()
+
+	base, _ := url.Parse(downSrv.URL)
+	w1, err := NewDurableWriter(http.DefaultClient, base, downSrv.URL+"/console", dir, "build-1", 0)
+	if err != nil {
+		t.Fatalf("NewDurableWriter: %v", err)
+	}
+	w1.Write([]byte("lost in transit"))
+	w1.Flush()
+	w1.Close()
+	downSrv.Close()
+
+	// Second writer resumes the same ring: the unacked line should be requeued, not lost.
+	var mu sync.Mutex
+	var gotBody string
+	upSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		gotBody = ungzip(t, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upSrv.Close()
+
+	base2, _ := url.Parse(upSrv.URL)
+	w2, err := NewDurableWriter(http.DefaultClient, base2, upSrv.URL+"/console", dir, "build-1", 0)
+	if err != nil {
+		t.Fatalf("NewDurableWriter (resume): %v", err)
+	}
+	defer w2.Close()
+	w2.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !bytes.HasSuffix([]byte(gotBody), []byte("lost in transit\n")) {
+		t.Errorf("resumed body = %q, want it to contain the unacked line", gotBody)
+	}
+}
+
+func TestResolveURL_RelativeAgainstBase(t *testing.T) {
+	base, _ := url.Parse("https://server.example:8154")
+	got, err := resolveURL(base, "/console/build-1")
+	if err != nil {
+		t.Fatalf("resolveURL: %v", err)
+	}
+	if want := "https://server.example:8154/console/build-1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveURL_AbsolutePassesThrough(t *testing.T) {
+	base, _ := url.Parse("https://server.example:8154")
+	got, err := resolveURL(base, "https://other.example/console")
+	if err != nil {
+		t.Fatalf("resolveURL: %v", err)
+	}
+	if want := "https://other.example/console"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
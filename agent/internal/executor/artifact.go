@@ -0,0 +1,117 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// ArtifactStore is the pluggable destination/source for the Upload/Download executors: a plain
+// directory (LocalStore) or an S3-compatible bucket (S3Store, see artifact_s3.go), selected by
+// config.Config.ArtifactStoreBackend via NewArtifactStore.
+
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArtifactStore persists and retrieves build artifacts by key (a slash-separated relative path).
+type ArtifactStore interface {
+	// Put uploads the file at localPath under key, writing human-readable progress to progress.
+	Put(key, localPath string, progress io.Writer) error
+	// Get downloads the object at key to localPath, writing human-readable progress to progress.
+	Get(key, localPath string, progress io.Writer) error
+}
+
+// ArtifactStoreSession is implemented by sessions (BuildSession) that carry a configured
+// ArtifactStore for the Upload/Download executors - the same optional-capability pattern as
+// BackendSession/ContainerSession/SecretSession.
+type ArtifactStoreSession interface {
+	Session
+	ArtifactStore() ArtifactStore
+}
+
+// NewArtifactStore builds the ArtifactStore selected by backend ("s3", or "local" - the default
+// for anything else): config.Config's ArtifactStoreDir for "local", or its
+// S3Bucket/S3Region/S3Endpoint/S3AccessKey/S3SecretKey for "s3".
+func NewArtifactStore(backend, localDir, s3Bucket, s3Region, s3Endpoint, s3AccessKey, s3SecretKey string) ArtifactStore {
+	if backend == "s3" {
+		return &S3Store{
+			Bucket:    s3Bucket,
+			Region:    s3Region,
+			Endpoint:  s3Endpoint,
+			AccessKey: s3AccessKey,
+			SecretKey: s3SecretKey,
+		}
+	}
+	if localDir == "" {
+		localDir = "./artifacts"
+	}
+	return &LocalStore{Dir: localDir}
+}
+
+// LocalStore is the "local" ArtifactStore backend: artifacts are plain files under Dir, keyed by
+// their relative path - suitable for a single agent, or a shared NFS/SMB mount across many.
+type LocalStore struct {
+	Dir string
+}
+
+func (l *LocalStore) Put(key, localPath string, progress io.Writer) error {
+	dest := filepath.Join(l.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("local artifact store: %w", err)
+	}
+	if err := copyFile(localPath, dest); err != nil {
+		return fmt.Errorf("local artifact store put %s: %w", key, err)
+	}
+	fmt.Fprintf(progress, "uploaded %s -> %s\n", localPath, dest)
+	return nil
+}
+
+func (l *LocalStore) Get(key, localPath string, progress io.Writer) error {
+	src := filepath.Join(l.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("local artifact store: %w", err)
+	}
+	if err := copyFile(src, localPath); err != nil {
+		return fmt.Errorf("local artifact store get %s: %w", key, err)
+	}
+	fmt.Fprintf(progress, "downloaded %s -> %s\n", src, localPath)
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// runCancelable runs fn in a goroutine and returns its result, polling session.Canceled() every
+// tick the way Git/Local/Docker do. Unlike those, there's no subprocess to kill on
+// cancellation - fn keeps running in the background and its eventual result is discarded - but
+// the caller isn't left blocked waiting on a Put/Get that may be stalled on a slow network.
+func runCancelable(session Session, fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			if session.Canceled() {
+				return fmt.Errorf("canceled")
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Store is the "s3" ArtifactStore backend: artifacts are objects in Bucket, keyed by their
+// relative path. Endpoint overrides the default AWS endpoint resolution for S3-compatible
+// services (e.g. MinIO); leave it empty to talk to AWS S3 itself.
+type S3Store struct {
+	Bucket    string
+	Region    string
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+}
+
+func (s *S3Store) client() *s3.Client {
+	cfg := aws.Config{Region: s.Region}
+	if s.AccessKey != "" {
+		cfg.Credentials = credentials.NewStaticCredentialsProvider(s.AccessKey, s.SecretKey, "")
+	}
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if s.Endpoint != "" {
+			o.BaseEndpoint = aws.String(s.Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+}
+
+func (s *S3Store) Put(key, localPath string, progress io.Writer) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("s3 artifact store: %w", err)
+	}
+	defer f.Close()
+
+	uploader := manager.NewUploader(s.client())
+	_, err = uploader.Upload(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+		Body:   f,
+	})
+	if err != nil {
+		return fmt.Errorf("s3 artifact store put %s: %w", key, err)
+	}
+	fmt.Fprintf(progress, "uploaded %s -> s3://%s/%s\n", localPath, s.Bucket, key)
+	return nil
+}
+
+func (s *S3Store) Get(key, localPath string, progress io.Writer) error {
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("s3 artifact store: %w", err)
+	}
+	out, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("s3 artifact store: %w", err)
+	}
+	defer out.Close()
+
+	downloader := manager.NewDownloader(s.client())
+	_, err = downloader.Download(context.Background(), out, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 artifact store get %s: %w", key, err)
+	}
+	fmt.Fprintf(progress, "downloaded s3://%s/%s -> %s\n", s.Bucket, key, localPath)
+	return nil
+}
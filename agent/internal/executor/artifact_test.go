@@ -0,0 +1,71 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStore_PutGetRoundTrip(t *testing.T) {
+	storeDir := t.TempDir()
+	store := &LocalStore{Dir: storeDir}
+
+	srcDir := t.TempDir()
+	src := filepath.Join(srcDir, "app.jar")
+	if err := os.WriteFile(src, []byte("binary"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	progress := &bytes.Buffer{}
+	if err := store.Put("builds/42/app.jar", src, progress); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if progress.Len() == 0 {
+		t.Error("Put should report progress")
+	}
+	if _, err := os.Stat(filepath.Join(storeDir, "builds", "42", "app.jar")); err != nil {
+		t.Errorf("Put did not write to the expected path: %v", err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "downloaded.jar")
+	if err := store.Get("builds/42/app.jar", dest, progress); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "binary" {
+		t.Errorf("Get content = %q, want %q", got, "binary")
+	}
+}
+
+func TestLocalStore_GetMissingKeyFails(t *testing.T) {
+	store := &LocalStore{Dir: t.TempDir()}
+	err := store.Get("no/such/key", filepath.Join(t.TempDir(), "out"), &bytes.Buffer{})
+	if err == nil {
+		t.Error("Get of a missing key should fail")
+	}
+}
+
+func TestNewArtifactStore_DefaultsToLocal(t *testing.T) {
+	store := NewArtifactStore("", "", "", "", "", "", "")
+	if _, ok := store.(*LocalStore); !ok {
+		t.Errorf("NewArtifactStore(\"\", ...) = %T, want *LocalStore", store)
+	}
+}
+
+func TestNewArtifactStore_S3(t *testing.T) {
+	store := NewArtifactStore("s3", "", "bucket", "us-east-1", "", "key", "secret")
+	s3Store, ok := store.(*S3Store)
+	if !ok {
+		t.Fatalf("NewArtifactStore(\"s3\", ...) = %T, want *S3Store", store)
+	}
+	if s3Store.Bucket != "bucket" || s3Store.Region != "us-east-1" {
+		t.Errorf("S3Store = %+v, want bucket/region set from args", s3Store)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Backend selection for shelled-out commands (Exec, Git): run as a plain OS process, or inside
+// a container via the Docker backend. Mirrors the per-step backend abstraction used by
+// cncd/pipeline (Woodpecker/Drone).
+
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Backend runs name with args in session's working dir, streaming stdout/stderr to
+// session.Console(), and blocks until the command exits, is canceled (session.Canceled()
+// flips), or fails to start.
+type Backend interface {
+	Run(session Session, cmd *protocol.BuildCommand, name string, args []string) error
+}
+
+// BackendSession is implemented by sessions that carry a configured default Backend
+// (BuildSession, wired from AGENT_EXECUTOR_BACKEND). Sessions that don't implement it - e.g.
+// the bare test doubles in exec_test.go - fall back to Local through ResolveBackend.
+type BackendSession interface {
+	Session
+	Backend() Backend
+}
+
+// ResolveBackend picks the Backend for cmd: its "backend" attribute if set ("local" or
+// "docker"), else session's configured default (BackendSession), else Local.
+func ResolveBackend(session Session, cmd *protocol.BuildCommand) Backend {
+	if name, ok := cmd.Attributes["backend"].(string); ok && name != "" {
+		if b := BackendByName(name); b != nil {
+			return b
+		}
+	}
+	if bs, ok := session.(BackendSession); ok && bs.Backend() != nil {
+		return bs.Backend()
+	}
+	return Local{}
+}
+
+// BackendByName returns the Backend for name ("local" or "docker"), or nil if unrecognized -
+// callers should fall back to Local rather than silently accept a typo.
+func BackendByName(name string) Backend {
+	switch name {
+	case "docker":
+		return Docker{}
+	case "local", "":
+		return Local{}
+	default:
+		return nil
+	}
+}
+
+// Local runs the command as a plain OS process - the agent's original Exec/Git behavior.
+type Local struct{}
+
+func (Local) Run(session Session, cmd *protocol.BuildCommand, name string, args []string) error {
+	c := exec.Command(name, args...)
+	c.Dir = session.WorkingDir()
+	c.Env = session.Env()
+	c.Stdout = session.Console()
+	c.Stderr = session.Console()
+
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("local backend start: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			if session.Canceled() {
+				_ = c.Process.Kill()
+				<-done
+				return fmt.Errorf("local backend: canceled")
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// backendSession adds a configurable default Backend and Docker container slot to mockSession.
+type backendSession struct {
+	mockSession
+	backend   Backend
+	container string
+}
+
+func (b *backendSession) Backend() Backend            { return b.backend }
+func (b *backendSession) DockerContainer() string      { return b.container }
+func (b *backendSession) SetDockerContainer(name string) { b.container = name }
+
+func TestBackendByName(t *testing.T) {
+	if _, ok := BackendByName("local").(Local); !ok {
+		t.Error(`BackendByName("local") should return Local{}`)
+	}
+	if _, ok := BackendByName("").(Local); !ok {
+		t.Error(`BackendByName("") should default to Local{}`)
+	}
+	if _, ok := BackendByName("docker").(Docker); !ok {
+		t.Error(`BackendByName("docker") should return Docker{}`)
+	}
+	if BackendByName("bogus") != nil {
+		t.Error(`BackendByName("bogus") should be nil`)
+	}
+}
+
+func TestResolveBackend_DefaultsToLocalWithoutBackendSession(t *testing.T) {
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandExec, Command: "echo"}
+
+	if _, ok := ResolveBackend(session, cmd).(Local); !ok {
+		t.Error("ResolveBackend should default to Local when session isn't a BackendSession")
+	}
+}
+
+func TestResolveBackend_UsesSessionDefault(t *testing.T) {
+	session := &backendSession{
+		mockSession: mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}, env: os.Environ()},
+		backend:     Docker{},
+	}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandExec, Command: "echo"}
+
+	if _, ok := ResolveBackend(session, cmd).(Docker); !ok {
+		t.Error("ResolveBackend should use the session's configured default Backend")
+	}
+}
+
+func TestResolveBackend_PerCommandAttributeOverridesSessionDefault(t *testing.T) {
+	session := &backendSession{
+		mockSession: mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}, env: os.Environ()},
+		backend:     Docker{},
+	}
+	cmd := &protocol.BuildCommand{
+		Name:       protocol.CommandExec,
+		Command:    "echo",
+		Attributes: map[string]interface{}{"backend": "local"},
+	}
+
+	if _, ok := ResolveBackend(session, cmd).(Local); !ok {
+		t.Error(`a "backend": "local" attribute should override the session default`)
+	}
+}
+
+func TestLocal_RunEcho(t *testing.T) {
+	buf := &bytes.Buffer{}
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: buf, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandExec, Command: "echo"}
+
+	if err := (Local{}).Run(session, cmd, "echo", []string{"hi"}); err != nil {
+		t.Fatalf("Local.Run: %v", err)
+	}
+	if got := buf.String(); got != "hi\n" && got != "hi\r\n" {
+		t.Errorf("console output = %q, want hi newline", got)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// ContainerSession is implemented by sessions (BuildSession) that can remember a running
+// Docker container across sibling subcommands of a compose, so a multi-step job starts one
+// container instead of one per step.
+type ContainerSession interface {
+	Session
+	// DockerContainer returns the name of this session's running container, or "" if none
+	// has been started yet.
+	DockerContainer() string
+	// SetDockerContainer records the name of the container started for this session.
+	SetDockerContainer(name string)
+}
+
+// StopContainer stops and removes the container previously started for session via
+// ContainerSession, if any. Intended to be deferred alongside closing the build console.
+func StopContainer(session Session) {
+	cs, ok := session.(ContainerSession)
+	if !ok {
+		return
+	}
+	name := cs.DockerContainer()
+	if name == "" {
+		return
+	}
+	_ = exec.Command("docker", "rm", "-f", name).Run()
+	cs.SetDockerContainer("")
+}
+
+// RegistryAuthSession is implemented by sessions (BuildSession) that carry registry credentials
+// (from config.Config) for authenticating an image pull. Sessions that don't implement it pull
+// anonymously - the same optional-capability pattern as ContainerSession/BackendSession.
+type RegistryAuthSession interface {
+	Session
+	// RegistryAuth returns the username/password to `docker login` with before a pull, or ""/""
+	// to pull anonymously.
+	RegistryAuth() (username, password string)
+}
+
+// dockerLogin logs in to the registry hosting image, if session carries non-empty registry
+// credentials via RegistryAuthSession.
+func dockerLogin(session Session, image string) error {
+	ras, ok := session.(RegistryAuthSession)
+	if !ok {
+		return nil
+	}
+	user, pass := ras.RegistryAuth()
+	if user == "" {
+		return nil
+	}
+	login := exec.Command("docker", "login", registryHost(image), "--username", user, "--password-stdin")
+	login.Stdin = strings.NewReader(pass)
+	login.Stdout = session.Console()
+	login.Stderr = session.Console()
+	if err := login.Run(); err != nil {
+		return fmt.Errorf("docker login: %w", err)
+	}
+	return nil
+}
+
+// registryHost extracts the registry host from image's first path segment (e.g.
+// "registry.example.com/team/app" -> "registry.example.com"), or "" for Docker Hub, where
+// `docker login` expects no server argument.
+func registryHost(image string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+	first := parts[0]
+	if strings.Contains(first, ".") || strings.Contains(first, ":") || first == "localhost" {
+		return first
+	}
+	return ""
+}
+
+// Docker runs the command in a container, configured via cmd.Attributes:
+//
+//	image      (string, required for the first command in a session) - image to run
+//	volumes    ([]interface{} of "host:container[:mode]" strings) - extra bind mounts
+//	networks   ([]interface{} of network names) - extra --network flags
+//	privileged (bool) - run --privileged
+//	entrypoint (string) - override the image entrypoint
+//	pull       (bool) - docker pull the image before running
+//
+// If session is a ContainerSession and already has a running container (a prior sibling
+// subcommand of the same compose started one), the command runs via "docker exec" in that
+// container instead of starting a new one.
+type Docker struct{}
+
+func (Docker) Run(session Session, cmd *protocol.BuildCommand, name string, args []string) error {
+	if cs, ok := session.(ContainerSession); ok {
+		if container := cs.DockerContainer(); container != "" {
+			return dockerExec(session, container, name, args)
+		}
+	}
+
+	image, _ := cmd.Attributes["image"].(string)
+	if image == "" {
+		return fmt.Errorf("docker backend: attributes.image is required")
+	}
+
+	if toBool(cmd.Attributes["pull"]) {
+		if err := dockerLogin(session, image); err != nil {
+			return err
+		}
+		pull := exec.Command("docker", "pull", image)
+		pull.Stdout = session.Console()
+		pull.Stderr = session.Console()
+		if err := pull.Run(); err != nil {
+			return fmt.Errorf("docker pull %s: %w", image, err)
+		}
+	}
+
+	cs, reusable := session.(ContainerSession)
+	if reusable {
+		containerName := fmt.Sprintf("ex-gocd-%s-%d", sanitizeContainerName(session.WorkingDir()), time.Now().UnixNano())
+		if err := dockerRunDetached(session, cmd, image, containerName); err != nil {
+			return err
+		}
+		cs.SetDockerContainer(containerName)
+		return dockerExec(session, containerName, name, args)
+	}
+
+	return dockerRunOnce(session, cmd, image, name, args)
+}
+
+// dockerRunOnce runs image with name+args as the container command, removing the container on
+// exit. Used when the session can't remember a container for reuse across sibling subcommands.
+func dockerRunOnce(session Session, cmd *protocol.BuildCommand, image, name string, args []string) error {
+	dockerArgs := append(dockerCommonArgs(session, cmd), image, name)
+	dockerArgs = append(dockerArgs, args...)
+	return runAndStream(session, append([]string{"run", "--rm"}, dockerArgs...))
+}
+
+// dockerRunDetached starts image as a long-running container (sleep infinity) so subsequent
+// sibling subcommands can "docker exec" into it.
+func dockerRunDetached(session Session, cmd *protocol.BuildCommand, image, containerName string) error {
+	dockerArgs := append([]string{"run", "-d", "--name", containerName}, dockerCommonArgs(session, cmd)...)
+	dockerArgs = append(dockerArgs, image, "sleep", "infinity")
+	out, err := exec.Command("docker", dockerArgs...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker run -d %s: %w: %s", image, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// dockerExec runs name+args inside an already-running container.
+func dockerExec(session Session, containerName, name string, args []string) error {
+	dockerArgs := []string{"exec", "-w", session.WorkingDir()}
+	for _, e := range session.Env() {
+		dockerArgs = append(dockerArgs, "-e", e)
+	}
+	dockerArgs = append(dockerArgs, containerName, name)
+	dockerArgs = append(dockerArgs, args...)
+	return runAndStream(session, dockerArgs)
+}
+
+// dockerCommonArgs builds the workdir/volume/network/privileged/entrypoint/env flags shared by
+// "docker run" invocations, mounting session.WorkingDir() into the container at the same path.
+func dockerCommonArgs(session Session, cmd *protocol.BuildCommand) []string {
+	wd := session.WorkingDir()
+	args := []string{"-w", wd, "-v", wd + ":" + wd}
+
+	for _, v := range toStringSlice(cmd.Attributes["volumes"]) {
+		args = append(args, "-v", v)
+	}
+	for _, n := range toStringSlice(cmd.Attributes["networks"]) {
+		args = append(args, "--network", n)
+	}
+	if toBool(cmd.Attributes["privileged"]) {
+		args = append(args, "--privileged")
+	}
+	if entrypoint, ok := cmd.Attributes["entrypoint"].(string); ok && entrypoint != "" {
+		args = append(args, "--entrypoint", entrypoint)
+	}
+	for _, e := range session.Env() {
+		args = append(args, "-e", e)
+	}
+	return args
+}
+
+// runAndStream runs `docker dockerArgs...`, streaming stdout/stderr to session.Console() and
+// killing the docker client (which stops the container via --rm/exec semantics) if
+// session.Canceled() flips.
+func runAndStream(session Session, dockerArgs []string) error {
+	c := exec.Command("docker", dockerArgs...)
+	c.Stdout = session.Console()
+	c.Stderr = session.Console()
+
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("docker backend start: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			if session.Canceled() {
+				_ = c.Process.Kill()
+				<-done
+				return fmt.Errorf("docker backend: canceled")
+			}
+		}
+	}
+}
+
+func sanitizeContainerName(s string) string {
+	replacer := strings.NewReplacer("/", "-", "\\", "-", " ", "-")
+	s = replacer.Replace(s)
+	if s == "" {
+		return "build"
+	}
+	return s
+}
+
+func toBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		parsed, _ := strconv.ParseBool(b)
+		return parsed
+	default:
+		return false
+	}
+}
+
+func toStringSlice(v interface{}) []string {
+	switch vs := v.(type) {
+	case []string:
+		return vs
+	case []interface{}:
+		out := make([]string, 0, len(vs))
+		for _, item := range vs {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func TestDocker_RequiresImageAttribute(t *testing.T) {
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandExec, Command: "echo"}
+
+	err := (Docker{}).Run(session, cmd, "echo", []string{"hi"})
+	if err == nil {
+		t.Error("Docker.Run without attributes.image should fail")
+	}
+}
+
+func TestDockerCommonArgs_IncludesWorkdirVolumesAndFlags(t *testing.T) {
+	session := &mockSession{wd: "/work/dir", ConsoleBuf: &bytes.Buffer{}, env: []string{"FOO=bar"}}
+	cmd := &protocol.BuildCommand{
+		Attributes: map[string]interface{}{
+			"volumes":    []interface{}{"/host:/container"},
+			"networks":   []interface{}{"build-net"},
+			"privileged": true,
+			"entrypoint": "/bin/sh",
+		},
+	}
+
+	args := dockerCommonArgs(session, cmd)
+
+	want := []string{
+		"-w", "/work/dir",
+		"-v", "/work/dir:/work/dir",
+		"-v", "/host:/container",
+		"--network", "build-net",
+		"--privileged",
+		"--entrypoint", "/bin/sh",
+		"-e", "FOO=bar",
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("dockerCommonArgs = %v, want %v", args, want)
+	}
+}
+
+func TestToBool(t *testing.T) {
+	cases := []struct {
+		in   interface{}
+		want bool
+	}{
+		{true, true},
+		{false, false},
+		{"true", true},
+		{"false", false},
+		{"not-a-bool", false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := toBool(c.in); got != c.want {
+			t.Errorf("toBool(%#v) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestToStringSlice(t *testing.T) {
+	got := toStringSlice([]interface{}{"a", "b", 3})
+	want := []string{"a", "b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("toStringSlice = %v, want %v", got, want)
+	}
+	if toStringSlice(nil) != nil {
+		t.Error("toStringSlice(nil) should be nil")
+	}
+}
+
+func TestStopContainer_NoOpWithoutContainerSession(t *testing.T) {
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}}
+	StopContainer(session) // must not panic for a plain Session
+}
+
+func TestDockerLogin_NoOpWithoutRegistryAuthSession(t *testing.T) {
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}}
+	if err := dockerLogin(session, "example.com/team/app"); err != nil {
+		t.Errorf("expected no-op for a plain Session, got %v", err)
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	cases := []struct{ image, want string }{
+		{"ubuntu", ""},
+		{"library/ubuntu", ""},
+		{"registry.example.com/team/app", "registry.example.com"},
+		{"localhost:5000/app", "localhost:5000"},
+		{"localhost/app", "localhost"},
+	}
+	for _, c := range cases {
+		if got := registryHost(c.image); got != c.want {
+			t.Errorf("registryHost(%q) = %q, want %q", c.image, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Download executor: fetch cmd.Src from the session's ArtifactStore to cmd.Dest.
+
+package executor
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Download runs the "download" BuildCommand: fetches the object at cmd.Src from the session's
+// ArtifactStore to cmd.Dest (relative to the command's working dir; defaults to cmd.Src's base
+// name). Sessions that don't implement ArtifactStoreSession fail the command - see Upload.
+func Download(session Session, cmd *protocol.BuildCommand) error {
+	as, ok := session.(ArtifactStoreSession)
+	if !ok {
+		return fmt.Errorf("download: session has no configured artifact store")
+	}
+	if cmd.Src == "" {
+		return fmt.Errorf("download: src is required")
+	}
+	dest := cmd.Dest
+	if dest == "" {
+		dest = filepath.Base(cmd.Src)
+	}
+	destPath := filepath.Join(session.WorkingDir(), dest)
+
+	store := as.ArtifactStore()
+	if err := runCancelable(session, func() error { return store.Get(cmd.Src, destPath, session.Console()) }); err != nil {
+		return fmt.Errorf("download %s: %w", cmd.Src, err)
+	}
+	return nil
+}
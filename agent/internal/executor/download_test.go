@@ -0,0 +1,72 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func TestDownload_FetchesToDest(t *testing.T) {
+	dir := t.TempDir()
+	session := newArtifactMockSession(dir)
+	store := session.store.(*LocalStore)
+	if err := os.MkdirAll(store.Dir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(store.Dir, "app.jar"), []byte("binary"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cmd := &protocol.BuildCommand{Name: protocol.CommandDownload, Src: "app.jar", Dest: "target/app.jar"}
+	if err := Download(session, cmd); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "target", "app.jar"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "binary" {
+		t.Errorf("content = %q, want %q", got, "binary")
+	}
+}
+
+func TestDownload_DefaultsDestToSrcBaseName(t *testing.T) {
+	dir := t.TempDir()
+	session := newArtifactMockSession(dir)
+	store := session.store.(*LocalStore)
+	if err := os.MkdirAll(store.Dir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(store.Dir, "app.jar"), []byte("binary"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	cmd := &protocol.BuildCommand{Name: protocol.CommandDownload, Src: "app.jar"}
+	if err := Download(session, cmd); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "app.jar")); err != nil {
+		t.Errorf("Download did not default dest to src's base name: %v", err)
+	}
+}
+
+func TestDownload_EmptySrcFails(t *testing.T) {
+	session := newArtifactMockSession(t.TempDir())
+	cmd := &protocol.BuildCommand{Name: protocol.CommandDownload, Src: ""}
+	if err := Download(session, cmd); err == nil {
+		t.Error("Download with empty src should fail")
+	}
+}
+
+func TestDownload_RequiresArtifactStoreSession(t *testing.T) {
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: nil, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandDownload, Src: "app.jar"}
+	if err := Download(session, cmd); err == nil {
+		t.Error("Download without an ArtifactStoreSession should fail")
+	}
+}
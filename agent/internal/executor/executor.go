@@ -1,6 +1,8 @@
 // Copyright © 2026 ex_gocd
 // Licensed under the Apache License, Version 2.0
-// Task execution: exec (run command + args), compose (run subcommands in order).
+// Task execution: exec (run command + args), compose (run subcommands in order). Exec's
+// command runs through a pluggable Backend (local process or Docker container, see backend.go
+// and docker.go) selected per BuildCommand or by the session's configured default.
 
 package executor
 
@@ -24,8 +26,12 @@ type Executor func(session Session, cmd *protocol.BuildCommand) error
 // Registry returns executors by command name (e.g. "exec", "compose", "git").
 func Registry() map[string]Executor {
 	return map[string]Executor{
-		protocol.CommandCompose: Compose,
-		protocol.CommandExec:    Exec,
-		protocol.CommandGit:     Git,
+		protocol.CommandCompose:    Compose,
+		protocol.CommandExec:       Exec,
+		protocol.CommandGit:        Git,
+		protocol.CommandUpload:     Upload,
+		protocol.CommandDownload:   Download,
+		protocol.CommandFetch:      Fetch,
+		protocol.CommandPluginExec: PluginExec,
 	}
 }
@@ -0,0 +1,106 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Fetch executor: download an arbitrary URL (a published artifact or a plain file) to cmd.Dest,
+// optionally verifying its content against cmd.Checksum - see remoting/builders, which is the
+// main producer of "fetch" BuildCommands (FetchArtifactBuilder, DownloadFileBuilder).
+
+package executor
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Fetch runs the "fetch" BuildCommand: GETs cmd.URL and writes it to cmd.Dest (relative to the
+// command's working dir), optionally verifying the result against cmd.Checksum (an "algo:hex"
+// pair, e.g. "sha256:abc123...") before reporting success.
+func Fetch(session Session, cmd *protocol.BuildCommand) error {
+	if cmd.URL == "" {
+		return fmt.Errorf("fetch: url is required")
+	}
+	dest := cmd.Dest
+	if dest == "" {
+		dest = filepath.Base(cmd.URL)
+	}
+	destPath := filepath.Join(session.WorkingDir(), dest)
+
+	if err := runCancelable(session, func() error { return fetchURL(cmd.URL, destPath, cmd.Checksum, session.Console()) }); err != nil {
+		return fmt.Errorf("fetch %s: %w", cmd.URL, err)
+	}
+	return nil
+}
+
+func fetchURL(url, destPath, checksum string, progress io.Writer) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	h, algo, err := checksumHasher(checksum)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = out
+	if h != nil {
+		w = io.MultiWriter(out, h)
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "fetched %s (%d bytes)\n", url, n)
+
+	if h == nil {
+		return nil
+	}
+	want := strings.SplitN(checksum, ":", 2)[1]
+	got := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(want, got) {
+		return fmt.Errorf("checksum mismatch: want %s:%s, got %s:%s", algo, want, algo, got)
+	}
+	return nil
+}
+
+// checksumHasher parses an "algo:hex" checksum string and returns the matching hash.Hash, or
+// (nil, "", nil) if checksum is empty (no verification requested).
+func checksumHasher(checksum string) (hash.Hash, string, error) {
+	if checksum == "" {
+		return nil, "", nil
+	}
+	parts := strings.SplitN(checksum, ":", 2)
+	if len(parts) != 2 {
+		return nil, "", fmt.Errorf("checksum: expected \"algo:hex\", got %q", checksum)
+	}
+	algo := parts[0]
+	switch algo {
+	case "sha256":
+		return sha256.New(), algo, nil
+	case "md5":
+		return md5.New(), algo, nil
+	default:
+		return nil, "", fmt.Errorf("checksum: unsupported algorithm %q", algo)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func TestFetch_DownloadsToDest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	session := &mockSession{wd: dir, ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandFetch, URL: server.URL + "/file.txt", Dest: "out/file.txt"}
+	if err := Fetch(session, cmd); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "out", "file.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != "payload" {
+		t.Errorf("content = %q, want %q", got, "payload")
+	}
+}
+
+func TestFetch_DefaultsDestToURLBaseName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	session := &mockSession{wd: dir, ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandFetch, URL: server.URL + "/file.txt"}
+	if err := Fetch(session, cmd); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "file.txt")); err != nil {
+		t.Errorf("Fetch did not default dest to URL's base name: %v", err)
+	}
+}
+
+func TestFetch_ChecksumMismatchFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("payload"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	session := &mockSession{wd: dir, ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandFetch, URL: server.URL + "/file.txt", Checksum: "sha256:deadbeef"}
+	if err := Fetch(session, cmd); err == nil {
+		t.Error("Fetch with a mismatched checksum should fail")
+	}
+}
+
+func TestFetch_ChecksumMatchSucceeds(t *testing.T) {
+	// sha256("payload") = 239f59ed55e737c77147cf55ad0c1b030b6d7ee748a7426952f9b852d5a935e5
+	const payload = "payload"
+	const sha256Sum = "239f59ed55e737c77147cf55ad0c1b030b6d7ee748a7426952f9b852d5a935e5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	session := &mockSession{wd: dir, ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandFetch, URL: server.URL + "/file.txt", Checksum: "sha256:" + sha256Sum}
+	if err := Fetch(session, cmd); err != nil {
+		t.Fatalf("Fetch with matching checksum should succeed: %v", err)
+	}
+}
+
+func TestFetch_EmptyURLFails(t *testing.T) {
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandFetch}
+	if err := Fetch(session, cmd); err == nil {
+		t.Error("Fetch with empty url should fail")
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Out-of-process executor plugins, borrowing the plugin-command model used by projects like
+// inetmock: a binary named gocd-agent-plugin-<name> living in AGENT_PLUGIN_DIR advertises the
+// BuildCommand names it implements via "--describe", and the agent shells out to it with "run"
+// whenever one of those commands appears in a build. A plugin crash or malformed manifest is
+// isolated to that one plugin/command - it never takes down the agent process.
+
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/executor"
+	"github.com/d-led/ex_gocd/agent/pkg/executorplugin"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+const binaryPrefix = "gocd-agent-plugin-"
+
+// Plugin is a discovered executor plugin binary and the manifest it described itself with.
+type Plugin struct {
+	Path     string
+	Manifest executorplugin.Manifest
+}
+
+// Discover finds plugin binaries in dir (files named gocd-agent-plugin-<name>), runs each with
+// "--describe" to fetch and health-check its manifest, and returns the ones that answered with
+// a valid manifest. A missing dir is not an error - there are simply no plugins. A plugin that
+// fails to describe itself is logged and skipped rather than failing discovery for the rest.
+func Discover(dir string) ([]*Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("plugin: read %s: %w", dir, err)
+	}
+
+	var plugins []*Plugin
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), binaryPrefix) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		manifest, err := describe(path)
+		if err != nil {
+			log.Printf("plugin %s: describe failed, skipping: %v", path, err)
+			continue
+		}
+		plugins = append(plugins, &Plugin{Path: path, Manifest: manifest})
+	}
+	return plugins, nil
+}
+
+func describe(path string) (executorplugin.Manifest, error) {
+	var manifest executorplugin.Manifest
+	out, err := exec.Command(path, "--describe").Output()
+	if err != nil {
+		return manifest, fmt.Errorf("run --describe: %w", err)
+	}
+	if err := json.Unmarshal(out, &manifest); err != nil {
+		return manifest, fmt.Errorf("parse manifest: %w", err)
+	}
+	if manifest.Name == "" || len(manifest.Commands) == 0 {
+		return manifest, fmt.Errorf("manifest missing name or commands")
+	}
+	return manifest, nil
+}
+
+// Register merges each plugin's advertised commands into registry as plugin-backed Executors,
+// so a BuildCommand naming one runs the plugin instead of failing with "unknown command". A
+// command name already present in registry is left untouched - core commands always win.
+func Register(registry map[string]executor.Executor, plugins []*Plugin) {
+	for _, p := range plugins {
+		for _, name := range p.Manifest.Commands {
+			if _, exists := registry[name]; exists {
+				log.Printf("plugin %s: command %q already registered, ignoring", p.Path, name)
+				continue
+			}
+			registry[name] = p.Executor()
+		}
+	}
+}
+
+// Executor returns an executor.Executor that runs this plugin's "run" subcommand for a
+// BuildCommand naming one of its advertised commands.
+func (p *Plugin) Executor() executor.Executor {
+	return func(session executor.Session, cmd *protocol.BuildCommand) error {
+		return p.run(session, cmd)
+	}
+}
+
+func (p *Plugin) run(session executor.Session, cmd *protocol.BuildCommand) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin %s: panicked: %v", p.Path, r)
+		}
+	}()
+
+	c := exec.Command(p.Path, "run")
+	c.Dir = session.WorkingDir()
+	c.Env = session.Env()
+	// Stderr is buffered rather than written straight to session.Console(): os/exec copies it in
+	// its own goroutine, which would race with streamEvents' concurrent writes to the same
+	// console. It's flushed below once streamEvents has finished (synchronized via eventErrCh).
+	var stderr bytes.Buffer
+	c.Stderr = &stderr
+
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("plugin %s: stdin pipe: %w", p.Path, err)
+	}
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("plugin %s: stdout pipe: %w", p.Path, err)
+	}
+
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("plugin %s: start: %w", p.Path, err)
+	}
+
+	envelope := executorplugin.Envelope{
+		WorkingDir: session.WorkingDir(),
+		Env:        session.Env(),
+		Command:    cmd,
+	}
+	encodeErr := json.NewEncoder(stdin).Encode(envelope)
+	stdin.Close()
+	if encodeErr != nil {
+		_ = c.Process.Kill()
+		<-waitFor(c)
+		return fmt.Errorf("plugin %s: write envelope: %w", p.Path, encodeErr)
+	}
+
+	eventErrCh := make(chan error, 1)
+	go streamEvents(stdout, session, eventErrCh)
+
+	done := waitFor(c)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case waitErr := <-done:
+			eventErr := <-eventErrCh
+			if stderr.Len() > 0 {
+				session.Console().Write(stderr.Bytes())
+			}
+			if eventErr != nil {
+				return fmt.Errorf("plugin %s: %w", p.Path, eventErr)
+			}
+			if waitErr != nil {
+				return fmt.Errorf("plugin %s: %w", p.Path, waitErr)
+			}
+			return nil
+		case <-ticker.C:
+			if session.Canceled() {
+				_ = c.Process.Signal(syscall.SIGTERM)
+			}
+		}
+	}
+}
+
+func waitFor(c *exec.Cmd) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- c.Wait() }()
+	return done
+}
+
+// streamEvents reads newline-delimited JSON events from the plugin's stdout, writing "log"
+// events to the session console, until stdout closes. The first "error" event's message, if
+// any, is sent to errCh once reading stops.
+func streamEvents(stdout io.Reader, session executor.Session, errCh chan<- error) {
+	var firstErr error
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var ev executorplugin.Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			continue
+		}
+		switch ev.Type {
+		case executorplugin.EventLog:
+			fmt.Fprintln(session.Console(), ev.Message)
+		case executorplugin.EventError:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s", ev.Message)
+			}
+		}
+	}
+	errCh <- firstErr
+}
@@ -0,0 +1,165 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/internal/executor"
+	"github.com/d-led/ex_gocd/agent/pkg/executorplugin"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+type mockSession struct {
+	wd       string
+	console  bytes.Buffer
+	env      []string
+	canceled bool
+}
+
+func (m *mockSession) WorkingDir() string { return m.wd }
+func (m *mockSession) Console() io.Writer { return &m.console }
+func (m *mockSession) Env() []string      { return m.env }
+func (m *mockSession) Canceled() bool     { return m.canceled }
+
+// writeScript writes an executable shell script to dir/name. Skips the test on platforms
+// without a POSIX shell (plugins are shelled-out binaries either way - this is just how the
+// test fakes one).
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts require a POSIX shell")
+	}
+	path := filepath.Join(dir, name)
+	script := "#!/bin/sh\n" + body
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return path
+}
+
+func TestDiscover_MissingDirIsNotAnError(t *testing.T) {
+	plugins, err := Discover(filepath.Join(t.TempDir(), "does-not-exist"))
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if plugins != nil {
+		t.Errorf("plugins = %v, want nil", plugins)
+	}
+}
+
+func TestDiscover_IgnoresNonMatchingFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "not-a-plugin", "echo hi")
+
+	plugins, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(plugins) != 0 {
+		t.Errorf("got %d plugins, want 0: %+v", len(plugins), plugins)
+	}
+}
+
+func TestDiscover_SkipsPluginWithBadManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "gocd-agent-plugin-broken", `echo 'not json'`)
+
+	plugins, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(plugins) != 0 {
+		t.Errorf("got %d plugins, want 0: %+v", len(plugins), plugins)
+	}
+}
+
+func TestDiscover_FindsValidPlugin(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "gocd-agent-plugin-s3", `echo '{"name":"s3","version":"1.0","commands":["s3-publish"]}'`)
+
+	plugins, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if len(plugins) != 1 {
+		t.Fatalf("got %d plugins, want 1", len(plugins))
+	}
+	if plugins[0].Manifest.Name != "s3" || plugins[0].Manifest.Commands[0] != "s3-publish" {
+		t.Errorf("unexpected manifest: %+v", plugins[0].Manifest)
+	}
+}
+
+func TestRegister_DoesNotOverrideCoreCommand(t *testing.T) {
+	core := func(executor.Session, *protocol.BuildCommand) error { return nil }
+	registry := map[string]executor.Executor{"exec": core}
+	p := &Plugin{Manifest: executorplugin.Manifest{Name: "fake", Commands: []string{"exec"}}}
+
+	Register(registry, []*Plugin{p})
+
+	if fmt.Sprintf("%p", registry["exec"]) != fmt.Sprintf("%p", core) {
+		t.Error("Register overwrote an existing core command")
+	}
+}
+
+func TestRegister_AddsPluginCommand(t *testing.T) {
+	registry := map[string]executor.Executor{}
+	p := &Plugin{Manifest: executorplugin.Manifest{Name: "fake", Commands: []string{"s3-publish"}}}
+
+	Register(registry, []*Plugin{p})
+
+	if registry["s3-publish"] == nil {
+		t.Error("Register did not add the plugin's command")
+	}
+}
+
+func TestPlugin_Run_StreamsLogEventsAndSucceeds(t *testing.T) {
+	dir := t.TempDir()
+	path := writeScript(t, dir, "gocd-agent-plugin-echo", `
+if [ "$1" = "--describe" ]; then
+  echo '{"name":"echo","version":"1.0","commands":["echo-line"]}'
+  exit 0
+fi
+cat >/dev/null
+echo '{"type":"log","message":"hello from plugin"}'
+echo '{"type":"status","message":"ok"}'
+`)
+	p := &Plugin{Path: path, Manifest: executorplugin.Manifest{Name: "echo", Commands: []string{"echo-line"}}}
+	session := &mockSession{wd: dir, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: "echo-line"}
+
+	if err := p.Executor()(session, cmd); err != nil {
+		t.Fatalf("plugin run: %v", err)
+	}
+	if got := session.console.String(); got != "hello from plugin\n" {
+		t.Errorf("console = %q, want %q", got, "hello from plugin\n")
+	}
+}
+
+func TestPlugin_Run_ReturnsErrorEvent(t *testing.T) {
+	dir := t.TempDir()
+	path := writeScript(t, dir, "gocd-agent-plugin-fail", `
+if [ "$1" = "--describe" ]; then
+  echo '{"name":"fail","version":"1.0","commands":["fail-cmd"]}'
+  exit 0
+fi
+cat >/dev/null
+echo '{"type":"error","message":"boom"}'
+exit 1
+`)
+	p := &Plugin{Path: path, Manifest: executorplugin.Manifest{Name: "fail", Commands: []string{"fail-cmd"}}}
+	session := &mockSession{wd: dir, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: "fail-cmd"}
+
+	err := p.Executor()(session, cmd)
+	if err == nil {
+		t.Fatal("expected error from plugin reporting an error event")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// PluginExec runs a GoCD Pluggable Task plugin (see remoting/builders.PluggableTaskBuilder)
+// through agent/pkg/plugins, translating its success/failure response into the same Passed/
+// Failed contract as any other BuildCommand.
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/pkg/plugins"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// TaskPluginSession is implemented by sessions that carry a configured task-plugins directory
+// (BuildSession, wired from AGENT_TASK_PLUGINS_DIR). Sessions that don't implement it - e.g. the
+// bare test doubles in exec_test.go - fail PluginExec with a clear "no task plugins directory"
+// error rather than guessing a default.
+type TaskPluginSession interface {
+	Session
+	TaskPluginsDir() string
+}
+
+// PluginExec runs the "pluginExec" BuildCommand: launches the task plugin named by
+// cmd.Attributes["pluginId"] and sends it an "execute" request carrying
+// cmd.Attributes["config"]. Nothing reaches session.Console(): the plugin's stdout carries only
+// its JSON response, and its stderr goes to the agent process's own stderr (see
+// plugins.Launcher.Execute).
+func PluginExec(session Session, cmd *protocol.BuildCommand) error {
+	pluginID, _ := cmd.Attributes["pluginId"].(string)
+	if pluginID == "" {
+		return fmt.Errorf("pluginExec: attributes.pluginId is required")
+	}
+	config, _ := cmd.Attributes["config"].(map[string]string)
+
+	dir := ""
+	if ps, ok := session.(TaskPluginSession); ok {
+		dir = ps.TaskPluginsDir()
+	}
+	launcher, err := plugins.Discover(dir, pluginID)
+	if err != nil {
+		return fmt.Errorf("pluginExec: %w", err)
+	}
+
+	body, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("pluginExec %s: marshal config: %w", pluginID, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var result plugins.PluginResult
+	err = runCancelableWithKill(session, cancel, func() error {
+		var execErr error
+		result, execErr = launcher.Execute(ctx, plugins.PluginRequest{Name: "execute", Body: body})
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("pluginExec %s: %w", pluginID, err)
+	}
+	if !result.Success {
+		if result.Message != "" {
+			return fmt.Errorf("pluginExec %s: %s", pluginID, result.Message)
+		}
+		return fmt.Errorf("pluginExec %s: task failed", pluginID)
+	}
+	return nil
+}
+
+// runCancelableWithKill runs fn in a goroutine and waits for it, polling session.Canceled()
+// every 500ms the way runCancelable does. Unlike runCancelable, fn here drives a subprocess
+// (launcher.Execute, via exec.CommandContext) that cancel can actually kill, so on cancellation
+// it calls cancel and waits for fn to return instead of abandoning it in the background.
+func runCancelableWithKill(session Session, cancel context.CancelFunc, fn func() error) error {
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ticker.C:
+			if session.Canceled() {
+				cancel()
+				return <-done
+			}
+		}
+	}
+}
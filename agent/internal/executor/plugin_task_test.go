@@ -0,0 +1,92 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+type taskPluginMockSession struct {
+	*mockSession
+	dir string
+}
+
+func (t *taskPluginMockSession) TaskPluginsDir() string { return t.dir }
+
+func newTaskPluginMockSession(t *testing.T, pluginsDir string) *taskPluginMockSession {
+	return &taskPluginMockSession{
+		mockSession: &mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}, env: os.Environ()},
+		dir:         pluginsDir,
+	}
+}
+
+// buildFakeTaskPlugin compiles agent/pkg/plugins' fakeplugin testdata binary into dir, named the
+// way agent/pkg/plugins.Discover expects (gocd-task-plugin-<pluginID>).
+func buildFakeTaskPlugin(t *testing.T, dir, pluginID string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	name := "gocd-task-plugin-" + pluginID
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	src := filepath.Join(wd, "..", "..", "pkg", "plugins", "testdata", "plugins", "fakeplugin")
+	cmd := exec.Command("go", "build", "-o", filepath.Join(dir, name), src)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build fake task plugin: %v\n%s", err, out)
+	}
+}
+
+func TestPluginExec_RunsPluginAndSucceeds(t *testing.T) {
+	dir := t.TempDir()
+	buildFakeTaskPlugin(t, dir, "fake")
+
+	session := newTaskPluginMockSession(t, dir)
+	cmd := &protocol.BuildCommand{
+		Name:       protocol.CommandPluginExec,
+		Attributes: map[string]interface{}{"pluginId": "fake", "config": map[string]string{"outcome": "ok"}},
+	}
+	if err := PluginExec(session, cmd); err != nil {
+		t.Fatalf("PluginExec: %v", err)
+	}
+}
+
+func TestPluginExec_FailsOnTaskFailure(t *testing.T) {
+	dir := t.TempDir()
+	buildFakeTaskPlugin(t, dir, "fake")
+
+	session := newTaskPluginMockSession(t, dir)
+	cmd := &protocol.BuildCommand{
+		Name:       protocol.CommandPluginExec,
+		Attributes: map[string]interface{}{"pluginId": "fake", "config": map[string]string{"outcome": "fail"}},
+	}
+	if err := PluginExec(session, cmd); err == nil {
+		t.Error("PluginExec should fail when the plugin reports success:false")
+	}
+}
+
+func TestPluginExec_RequiresPluginId(t *testing.T) {
+	session := newTaskPluginMockSession(t, t.TempDir())
+	cmd := &protocol.BuildCommand{Name: protocol.CommandPluginExec}
+	if err := PluginExec(session, cmd); err == nil {
+		t.Error("PluginExec without attributes.pluginId should fail")
+	}
+}
+
+func TestPluginExec_RequiresTaskPluginSession(t *testing.T) {
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandPluginExec, Attributes: map[string]interface{}{"pluginId": "fake"}}
+	if err := PluginExec(session, cmd); err == nil {
+		t.Error("PluginExec without a TaskPluginSession should fail to discover any plugin")
+	}
+}
@@ -0,0 +1,16 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"github.com/d-led/ex_gocd/agent/internal/secrets"
+)
+
+// SecretSession is implemented by sessions that carry resolved build secrets (BuildSession),
+// mirroring the BackendSession/ContainerSession pattern: Session itself stays minimal so
+// test doubles that don't care about secrets don't need to implement this.
+type SecretSession interface {
+	Session
+	Secrets() []secrets.Secret
+}
@@ -0,0 +1,51 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Upload executor: push files matching cmd.Src to the session's ArtifactStore under cmd.Dest.
+
+package executor
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Upload runs the "upload" BuildCommand: every file matching cmd.Src (a glob pattern relative to
+// the command's working dir) is stored under cmd.Dest plus its path relative to the working dir,
+// via the session's ArtifactStore. Sessions that don't implement ArtifactStoreSession fail the
+// command rather than silently skipping it - unlike the optional-capability Backend/Container/
+// Secret sessions, there's no sane default artifact store to fall back to.
+func Upload(session Session, cmd *protocol.BuildCommand) error {
+	as, ok := session.(ArtifactStoreSession)
+	if !ok {
+		return fmt.Errorf("upload: session has no configured artifact store")
+	}
+	if cmd.Src == "" {
+		return fmt.Errorf("upload: src is required")
+	}
+
+	matches, err := filepath.Glob(filepath.Join(session.WorkingDir(), cmd.Src))
+	if err != nil {
+		return fmt.Errorf("upload: %w", err)
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("upload: no files matched %s", cmd.Src)
+	}
+
+	store := as.ArtifactStore()
+	for _, match := range matches {
+		if session.Canceled() {
+			return fmt.Errorf("upload: canceled")
+		}
+		rel, err := filepath.Rel(session.WorkingDir(), match)
+		if err != nil {
+			rel = filepath.Base(match)
+		}
+		key := filepath.ToSlash(filepath.Join(cmd.Dest, rel))
+		if err := runCancelable(session, func() error { return store.Put(key, match, session.Console()) }); err != nil {
+			return fmt.Errorf("upload %s: %w", match, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package executor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+type artifactMockSession struct {
+	*mockSession
+	store ArtifactStore
+}
+
+func (a *artifactMockSession) ArtifactStore() ArtifactStore { return a.store }
+
+func newArtifactMockSession(wd string) *artifactMockSession {
+	return &artifactMockSession{
+		mockSession: &mockSession{wd: wd, ConsoleBuf: &bytes.Buffer{}, env: os.Environ()},
+		store:       &LocalStore{Dir: filepath.Join(wd, "..", "store")},
+	}
+}
+
+func TestUpload_MatchesGlobAndStoresUnderDest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "out.log"), []byte("log"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	session := newArtifactMockSession(dir)
+	cmd := &protocol.BuildCommand{Name: protocol.CommandUpload, Src: "*.log", Dest: "build-1"}
+
+	if err := Upload(session, cmd); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	store := session.store.(*LocalStore)
+	if _, err := os.Stat(filepath.Join(store.Dir, "build-1", "out.log")); err != nil {
+		t.Errorf("Upload did not store the matched file: %v", err)
+	}
+}
+
+func TestUpload_NoMatchesFails(t *testing.T) {
+	session := newArtifactMockSession(t.TempDir())
+	cmd := &protocol.BuildCommand{Name: protocol.CommandUpload, Src: "*.missing"}
+	if err := Upload(session, cmd); err == nil {
+		t.Error("Upload with no matching files should fail")
+	}
+}
+
+func TestUpload_EmptySrcFails(t *testing.T) {
+	session := newArtifactMockSession(t.TempDir())
+	cmd := &protocol.BuildCommand{Name: protocol.CommandUpload, Src: ""}
+	if err := Upload(session, cmd); err == nil {
+		t.Error("Upload with empty src should fail")
+	}
+}
+
+func TestUpload_RequiresArtifactStoreSession(t *testing.T) {
+	session := &mockSession{wd: t.TempDir(), ConsoleBuf: &bytes.Buffer{}, env: os.Environ()}
+	cmd := &protocol.BuildCommand{Name: protocol.CommandUpload, Src: "*.log"}
+	if err := Upload(session, cmd); err == nil {
+		t.Error("Upload without an ArtifactStoreSession should fail")
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Structured logging for agent.Agent, so cancel/build/console events for a single job can be
+// correlated across concurrent agents in a shared log aggregator (see Agent.handleBuild).
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// New creates the process-wide structured logger for --log-format/AGENT_LOG_FORMAT: "json" for
+// a JSON handler suited to a log aggregator, anything else (including "" or "text") for slog's
+// human-readable text handler.
+func New(format string) *slog.Logger {
+	var handler slog.Handler
+	if format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, nil)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, nil)
+	}
+	return slog.New(handler)
+}
+
+type contextKey struct{}
+
+// WithLogger returns a copy of ctx carrying logger, retrievable by FromContext - this is how
+// Agent.handleBuild threads a build_id-scoped logger into runBuildCommand/runOneCommand.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger stored by WithLogger, or slog.Default() if ctx carries none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,34 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestNew_JSONVsText(t *testing.T) {
+	if _, ok := New("json").Handler().(*slog.JSONHandler); !ok {
+		t.Error(`New("json") should use a JSON handler`)
+	}
+	if _, ok := New("text").Handler().(*slog.TextHandler); !ok {
+		t.Error(`New("text") should use a text handler`)
+	}
+	if _, ok := New("").Handler().(*slog.TextHandler); !ok {
+		t.Error(`New("") should default to a text handler`)
+	}
+}
+
+func TestWithLoggerAndFromContext(t *testing.T) {
+	logger := New("text").With("build_id", "job1")
+	ctx := WithLogger(context.Background(), logger)
+
+	if got := FromContext(ctx); got != logger {
+		t.Error("FromContext should return the logger stored by WithLogger")
+	}
+	if got := FromContext(context.Background()); got == nil {
+		t.Error("FromContext should fall back to a non-nil default logger")
+	}
+}
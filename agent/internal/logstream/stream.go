@@ -0,0 +1,233 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// logstream persists per-job payloads (console log batches) to a disk-backed ring with
+// monotonically increasing IDs, and fans them out to local subscribers - borrowed from Coder's
+// provisioner log-notify pattern (publish "lines landed after ID N" so any subscriber,
+// reconnecting or brand new, can resume from a known offset instead of replaying everything or
+// losing what it missed) - so a console log upload can survive a transient network hiccup, or
+// even an agent restart, without dropping build output.
+
+package logstream
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Line is one payload persisted to the ring, tagged with its monotonically increasing ID
+// (1-based; 0 means "before the first line", the zero value Since/NewWriter starts replay from).
+type Line struct {
+	ID   int64
+	Data []byte
+}
+
+// record is Line's on-disk ndjson encoding. Data is base64 since a console log line may contain
+// arbitrary bytes, including embedded newlines, that would otherwise break line-oriented replay.
+type record struct {
+	ID   int64  `json:"id"`
+	Data string `json:"data"`
+}
+
+// Stream is a durable, resumable ring for one job's console output: every Append is persisted
+// to <workingDir>/logs/<buildLocator>.ndjson before being fanned out to subscribers, so a
+// subscriber that reconnects (or an agent that restarts) can recover unsent lines via Since
+// instead of losing them to an in-memory-only buffer.
+type Stream struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	nextID int64
+
+	subMu     sync.Mutex
+	subs      map[int]chan Line
+	nextSubID int
+}
+
+// Open creates or resumes the ring file for buildLocator under workingDir/logs, recovering
+// nextID from any lines already on disk (e.g. after an agent restart mid-build).
+func Open(workingDir, buildLocator string) (*Stream, error) {
+	dir := filepath.Join(workingDir, "logs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("logstream: %w", err)
+	}
+	path := filepath.Join(dir, sanitizeName(buildLocator)+".ndjson")
+
+	lastID, err := lastLineID(path)
+	if err != nil {
+		return nil, fmt.Errorf("logstream: reading existing ring: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("logstream: %w", err)
+	}
+	return &Stream{path: path, file: f, nextID: lastID + 1, subs: make(map[int]chan Line)}, nil
+}
+
+func sanitizeName(s string) string {
+	s = strings.ReplaceAll(s, "/", "_")
+	s = strings.ReplaceAll(s, "\\", "_")
+	if s == "" {
+		s = "default"
+	}
+	return s
+}
+
+// lastLineID scans an existing ring file (if any) and returns the highest Line.ID found, 0 if
+// the file doesn't exist or is empty.
+func lastLineID(path string) (int64, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var last int64
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // tolerate a truncated final line from a crash mid-write
+		}
+		if r.ID > last {
+			last = r.ID
+		}
+	}
+	return last, scanner.Err()
+}
+
+// Append assigns data the next sequence ID, persists it to the ring, and publishes it to every
+// current Subscribe-r.
+func (s *Stream) Append(data []byte) (Line, error) {
+	s.mu.Lock()
+	l := Line{ID: s.nextID, Data: data}
+	s.nextID++
+	rec := record{ID: l.ID, Data: base64.StdEncoding.EncodeToString(data)}
+	encoded, err := json.Marshal(rec)
+	if err != nil {
+		s.mu.Unlock()
+		return Line{}, fmt.Errorf("logstream: marshal line %d: %w", l.ID, err)
+	}
+	encoded = append(encoded, '\n')
+	_, werr := s.file.Write(encoded)
+	s.mu.Unlock()
+	if werr != nil {
+		return Line{}, fmt.Errorf("logstream: persisting line %d: %w", l.ID, werr)
+	}
+	s.publish(l)
+	return l, nil
+}
+
+// Since replays every persisted line with ID > afterID, for a subscriber resuming from a known
+// offset (a reconnecting consumer, or the agent recovering a ring left over from a crash).
+func (s *Stream) Since(afterID int64) ([]Line, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("logstream: %w", err)
+	}
+	defer f.Close()
+
+	var out []Line
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if r.ID <= afterID {
+			continue
+		}
+		data, derr := base64.StdEncoding.DecodeString(r.Data)
+		if derr != nil {
+			continue
+		}
+		out = append(out, Line{ID: r.ID, Data: data})
+	}
+	return out, scanner.Err()
+}
+
+// Subscribe returns a channel that receives every Line appended from now on, and an unsubscribe
+// function to stop delivery and release the channel. Multiple subscribers (a console log
+// sender, a local tail command, a future web UI) can observe the same job's stream
+// independently, each catching up on what it missed via Since.
+func (s *Stream) Subscribe() (<-chan Line, func()) {
+	ch := make(chan Line, 256)
+	s.subMu.Lock()
+	id := s.nextSubID
+	s.nextSubID++
+	s.subs[id] = ch
+	s.subMu.Unlock()
+
+	return ch, func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		if sub, ok := s.subs[id]; ok {
+			delete(s.subs, id)
+			close(sub)
+		}
+	}
+}
+
+func (s *Stream) publish(l Line) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for _, ch := range s.subs {
+		select {
+		case ch <- l:
+		default:
+			// Slow subscriber: drop rather than block Append - every line is durable on disk
+			// regardless of fan-out delivery, so it can always catch up via Since.
+		}
+	}
+}
+
+// NextID returns the ID that will be assigned to the next Append call. Since returns lines with
+// IDs above its argument, so a new subscriber passes NextID()-1 to skip the lines already on disk.
+func (s *Stream) NextID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextID
+}
+
+// AckPath is the sidecar file a consumer records its last server-acked ID to (see WriteAck),
+// so a new process can resume from there via Since instead of re-sending or losing lines.
+func (s *Stream) AckPath() string { return s.path + ".acked" }
+
+// Close closes the ring file. Subscribers are not closed individually - unsubscribe each via
+// the func returned from Subscribe.
+func (s *Stream) Close() error {
+	return s.file.Close()
+}
+
+// ReadAck returns the last acked ID recorded at ackPath, or 0 if none has been written yet.
+func ReadAck(ackPath string) (int64, error) {
+	data, err := os.ReadFile(ackPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, nil // tolerate a corrupt ack file - worst case is replaying already-acked lines
+	}
+	return id, nil
+}
+
+// WriteAck records id as the last acked ID at ackPath.
+func WriteAck(ackPath string, id int64) error {
+	return os.WriteFile(ackPath, []byte(strconv.FormatInt(id, 10)), 0644)
+}
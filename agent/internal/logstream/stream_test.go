@@ -0,0 +1,180 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package logstream
+
+import (
+	"testing"
+)
+
+func TestStream_AppendAssignsMonotonicIDs(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, "p/1/s/1/job")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	l1, err := s.Append([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	l2, err := s.Append([]byte("world"))
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if l1.ID != 1 || l2.ID != 2 {
+		t.Errorf("IDs = %d, %d, want 1, 2", l1.ID, l2.ID)
+	}
+}
+
+func TestStream_ResumesNextIDAcrossOpen(t *testing.T) {
+	dir := t.TempDir()
+	s1, err := Open(dir, "job")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	s1.Append([]byte("line1"))
+	s1.Append([]byte("line2"))
+	s1.Close()
+
+	s2, err := Open(dir, "job")
+	if err != nil {
+		t.Fatalf("Open (resume): %v", err)
+	}
+	defer s2.Close()
+	if got := s2.NextID(); got != 3 {
+		t.Errorf("NextID after resume = %d, want 3", got)
+	}
+	l3, err := s2.Append([]byte("line3"))
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if l3.ID != 3 {
+		t.Errorf("l3.ID = %d, want 3", l3.ID)
+	}
+}
+
+func TestStream_SinceReturnsOnlyLinesAfterID(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, "job")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	s.Append([]byte("a"))
+	s.Append([]byte("b"))
+	s.Append([]byte("c"))
+
+	lines, err := s.Since(1)
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(lines) != 2 || string(lines[0].Data) != "b" || string(lines[1].Data) != "c" {
+		t.Errorf("Since(1) = %+v, want [b, c]", lines)
+	}
+
+	all, err := s.Since(0)
+	if err != nil {
+		t.Fatalf("Since: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("Since(0) returned %d lines, want 3", len(all))
+	}
+}
+
+func TestStream_SubscribeReceivesLiveAppends(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, "job")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	ch, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	s.Append([]byte("hello"))
+	select {
+	case l := <-ch:
+		if string(l.Data) != "hello" {
+			t.Errorf("got %q, want %q", l.Data, "hello")
+		}
+	default:
+		t.Fatal("expected a line on the subscriber channel")
+	}
+}
+
+func TestStream_MultipleSubscribersEachGetTheLine(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, "job")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	ch1, unsub1 := s.Subscribe()
+	defer unsub1()
+	ch2, unsub2 := s.Subscribe()
+	defer unsub2()
+
+	s.Append([]byte("fanned out"))
+
+	for i, ch := range []<-chan Line{ch1, ch2} {
+		select {
+		case l := <-ch:
+			if string(l.Data) != "fanned out" {
+				t.Errorf("subscriber %d got %q", i, l.Data)
+			}
+		default:
+			t.Errorf("subscriber %d got nothing", i)
+		}
+	}
+}
+
+func TestStream_UnsubscribeStopsDelivery(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, "job")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+
+	ch, unsubscribe := s.Subscribe()
+	unsubscribe()
+
+	s.Append([]byte("after unsubscribe"))
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after unsubscribe")
+	}
+}
+
+func TestReadWriteAck(t *testing.T) {
+	dir := t.TempDir()
+	ackPath := dir + "/test.acked"
+
+	if got, err := ReadAck(ackPath); err != nil || got != 0 {
+		t.Fatalf("ReadAck before any write: got %d, %v, want 0, nil", got, err)
+	}
+
+	if err := WriteAck(ackPath, 42); err != nil {
+		t.Fatalf("WriteAck: %v", err)
+	}
+	if got, err := ReadAck(ackPath); err != nil || got != 42 {
+		t.Fatalf("ReadAck: got %d, %v, want 42, nil", got, err)
+	}
+}
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"p/1/s/1/job": "p_1_s_1_job",
+		"":            "default",
+		`a\b`:         "a_b",
+	}
+	for in, want := range tests {
+		if got := sanitizeName(in); got != want {
+			t.Errorf("sanitizeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
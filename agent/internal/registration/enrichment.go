@@ -0,0 +1,151 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Registration enrichment: lets an external webhook (CMDB, cloud metadata, ...) contribute
+// resources/environments/extra form fields instead of baking them into env vars per host.
+
+package registration
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+)
+
+const registrationWebhookTimeout = 5 * time.Second
+
+// RegistrationEnricher augments the registration form data before it's POSTed to the server.
+type RegistrationEnricher interface {
+	Enrich(ctx context.Context, base url.Values) (url.Values, error)
+}
+
+// webhookEnricher POSTs the agent's identity to a configured URL and merges the JSON
+// response (resources, environments, extraFormFields) into the registration form.
+type webhookEnricher struct {
+	cfg        *config.Config
+	url        string
+	secret     string
+	strict     bool
+	httpClient *http.Client
+}
+
+// newEnricherFromEnv builds a webhookEnricher from EX_GOCD_REGISTRATION_WEBHOOK_* env vars,
+// or returns nil if no webhook URL is configured.
+func newEnricherFromEnv(cfg *config.Config) RegistrationEnricher {
+	webhookURL := os.Getenv("EX_GOCD_REGISTRATION_WEBHOOK_URL")
+	if webhookURL == "" {
+		return nil
+	}
+	return &webhookEnricher{
+		cfg:        cfg,
+		url:        webhookURL,
+		secret:     os.Getenv("EX_GOCD_REGISTRATION_WEBHOOK_SECRET"),
+		strict:     strings.EqualFold(os.Getenv("EX_GOCD_REGISTRATION_WEBHOOK_STRICT"), "true"),
+		httpClient: &http.Client{Timeout: registrationWebhookTimeout},
+	}
+}
+
+// webhookIdentity is the JSON body POSTed to the enrichment webhook.
+type webhookIdentity struct {
+	Hostname       string `json:"hostname"`
+	UUID           string `json:"uuid"`
+	IPAddress      string `json:"ipAddress"`
+	OS             string `json:"os"`
+	Arch           string `json:"arch"`
+	ElasticAgentID string `json:"elasticAgentId"`
+}
+
+// webhookResult is the expected JSON response from the enrichment webhook.
+type webhookResult struct {
+	Resources       []string          `json:"resources"`
+	Environments    []string          `json:"environments"`
+	ExtraFormFields map[string]string `json:"extraFormFields"`
+}
+
+// Enrich POSTs the agent's identity to the webhook and merges resources/environments/extra
+// fields into base. In strict mode a webhook failure fails registration; otherwise it's
+// logged and base is returned unchanged so a flaky CMDB doesn't block the agent from joining.
+func (w *webhookEnricher) Enrich(ctx context.Context, base url.Values) (url.Values, error) {
+	result, err := w.fetch(ctx)
+	if err != nil {
+		if w.strict {
+			return nil, fmt.Errorf("registration enrichment webhook %s: %w", w.url, err)
+		}
+		log.Printf("registration enrichment webhook %s failed, continuing without it: %v", w.url, err)
+		return base, nil
+	}
+
+	merged := url.Values{}
+	for k, v := range base {
+		merged[k] = append([]string(nil), v...)
+	}
+	if len(result.Resources) > 0 {
+		merged.Set("agentAutoRegisterResources", strings.Join(result.Resources, ","))
+	}
+	if len(result.Environments) > 0 {
+		merged.Set("agentAutoRegisterEnvironments", strings.Join(result.Environments, ","))
+	}
+	for k, v := range result.ExtraFormFields {
+		merged.Set(k, v)
+	}
+	return merged, nil
+}
+
+func (w *webhookEnricher) fetch(ctx context.Context) (*webhookResult, error) {
+	identity := webhookIdentity{
+		Hostname:       w.cfg.Hostname,
+		UUID:           w.cfg.UUID,
+		IPAddress:      w.cfg.IPAddress,
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+		ElasticAgentID: w.cfg.ElasticAgentID,
+	}
+	body, err := json.Marshal(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set("X-Ex-Gocd-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result webhookResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &result, nil
+}
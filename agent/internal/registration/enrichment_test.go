@@ -0,0 +1,117 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package registration
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookEnricher_MergesResourcesAndEnvironments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var identity webhookIdentity
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&identity))
+		assert.Equal(t, "agent-host", identity.Hostname)
+
+		_ = json.NewEncoder(w).Encode(webhookResult{
+			Resources:    []string{"docker", "linux"},
+			Environments: []string{"staging"},
+			ExtraFormFields: map[string]string{
+				"agentAutoRegisterHostname": "override-host",
+			},
+		})
+	}))
+	defer server.Close()
+
+	cfg := testConfig(t)
+	cfg.Hostname = "agent-host"
+	enricher := &webhookEnricher{cfg: cfg, url: server.URL, httpClient: server.Client()}
+
+	base := url.Values{"hostname": {"agent-host"}}
+	merged, err := enricher.Enrich(context.Background(), base)
+	require.NoError(t, err)
+
+	assert.Equal(t, "docker,linux", merged.Get("agentAutoRegisterResources"))
+	assert.Equal(t, "staging", merged.Get("agentAutoRegisterEnvironments"))
+	assert.Equal(t, "override-host", merged.Get("agentAutoRegisterHostname"))
+	assert.Equal(t, "agent-host", merged.Get("hostname"), "original fields must survive the merge")
+}
+
+func TestWebhookEnricher_SignsRequestWhenSecretConfigured(t *testing.T) {
+	const secret = "s3cr3t"
+	var gotSignature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotSignature = r.Header.Get("X-Ex-Gocd-Signature")
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), gotSignature)
+		_ = json.NewEncoder(w).Encode(webhookResult{})
+	}))
+	defer server.Close()
+
+	cfg := testConfig(t)
+	enricher := &webhookEnricher{cfg: cfg, url: server.URL, secret: secret, httpClient: server.Client()}
+
+	_, err := enricher.Enrich(context.Background(), url.Values{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, gotSignature)
+}
+
+func TestWebhookEnricher_NonStrictFailureFallsBackToBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := testConfig(t)
+	enricher := &webhookEnricher{cfg: cfg, url: server.URL, httpClient: server.Client()}
+
+	base := url.Values{"hostname": {"agent-host"}}
+	merged, err := enricher.Enrich(context.Background(), base)
+	require.NoError(t, err)
+	assert.Equal(t, base, merged)
+}
+
+func TestWebhookEnricher_StrictFailurePropagatesError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := testConfig(t)
+	enricher := &webhookEnricher{cfg: cfg, url: server.URL, strict: true, httpClient: server.Client()}
+
+	_, err := enricher.Enrich(context.Background(), url.Values{})
+	assert.Error(t, err)
+}
+
+func TestNewEnricherFromEnv_NilWhenURLUnset(t *testing.T) {
+	cfg := testConfig(t)
+	assert.Nil(t, newEnricherFromEnv(cfg))
+}
+
+func TestNewEnricherFromEnv_ConfiguredFromEnvVars(t *testing.T) {
+	t.Setenv("EX_GOCD_REGISTRATION_WEBHOOK_URL", "https://cmdb.example/enrich")
+	t.Setenv("EX_GOCD_REGISTRATION_WEBHOOK_SECRET", "topsecret")
+	t.Setenv("EX_GOCD_REGISTRATION_WEBHOOK_STRICT", "true")
+
+	cfg := testConfig(t)
+	enricher, ok := newEnricherFromEnv(cfg).(*webhookEnricher)
+	require.True(t, ok)
+	assert.Equal(t, "https://cmdb.example/enrich", enricher.url)
+	assert.Equal(t, "topsecret", enricher.secret)
+	assert.True(t, enricher.strict)
+}
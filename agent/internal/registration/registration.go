@@ -4,13 +4,21 @@
 package registration
 
 import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -20,28 +28,48 @@ import (
 	"strings"
 	"time"
 
+	"github.com/d-led/ex_gocd/agent/internal/backoff"
 	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/internal/tlsconfig"
 	"github.com/d-led/ex_gocd/agent/pkg/protocol"
 )
 
+// Agent private key types selectable via EX_GOCD_AGENT_KEY_TYPE; ECDSA P-256 is the default.
+const (
+	keyTypeECDSA = "ecdsa-p256"
+	keyTypeRSA   = "rsa-2048"
+)
+
 // Registrar handles agent registration with the server
 type Registrar struct {
 	config     *config.Config
 	httpClient *http.Client
+	enricher   RegistrationEnricher
+	trustStore *TrustStore
 }
 
-// New creates a new Registrar
+// New creates a new Registrar. If EX_GOCD_REGISTRATION_WEBHOOK_URL is set, registration form
+// data is enriched via that webhook before every registration/renewal POST.
 func New(cfg *config.Config) *Registrar {
 	return &Registrar{
-		config: cfg,
+		config:     cfg,
+		enricher:   newEnricherFromEnv(cfg),
+		trustStore: NewTrustStore(cfg, &http.Client{Timeout: 10 * time.Second}),
 	}
 }
 
+// TrustStore returns the Registrar's TrustStore, so callers (e.g. cmd.runAgent) can start its
+// periodic CRL refresh alongside certificate renewal.
+func (r *Registrar) TrustStore() *TrustStore {
+	return r.trustStore
+}
+
 // Register performs the full registration flow with retry logic:
 // 1. Read GoCD server CA certificate
 // 2. Request token from server
 // 3. Register with token and get agent certificates (retries if pending approval)
-func (r *Registrar) Register() error {
+// ctx governs the pending-approval retry wait, so shutdown isn't blocked behind it.
+func (r *Registrar) Register(ctx context.Context) error {
 	// Read server CA certificate
 	if err := r.readServerCACert(); err != nil {
 		return fmt.Errorf("failed to read server CA: %w", err)
@@ -60,33 +88,38 @@ func (r *Registrar) Register() error {
 	}
 
 	// Register and get certificates with retry for pending approval
-	if err := r.registerWithRetry(); err != nil {
+	if err := r.registerWithRetry(ctx); err != nil {
 		return fmt.Errorf("failed to register: %w", err)
 	}
 
 	return nil
 }
 
-// readServerCACert downloads the server CA certificate
+// readServerCACert downloads the server CA certificate. It's trust-on-first-use - beyond
+// that, the existing file is reused as-is unless EX_GOCD_SERVER_CA_REFRESH=true or the cached
+// cert is within 30 days of expiry, in which case it's re-downloaded and, if an
+// EX_GOCD_SERVER_CA_SPKI_PIN is configured, only swapped in when the pin still matches (a pin
+// mismatch aborts without touching the existing file).
 func (r *Registrar) readServerCACert() error {
 	caFile := r.config.GoServerCAFile()
-
-	// Skip if CA file already exists
-	if _, err := os.Stat(caFile); err == nil {
-		return nil
-	}
-
 	serverURL := r.config.ServerURL
 
 	// Only get CA cert if using HTTPS
 	if serverURL.Scheme != "https" {
 		// For HTTP servers, create a dummy CA file for consistency
+		if _, err := os.Stat(caFile); err == nil {
+			return nil
+		}
 		if err := os.MkdirAll(filepath.Dir(caFile), 0755); err != nil {
 			return err
 		}
 		return os.WriteFile(caFile, []byte("# Not using TLS\n"), 0644)
 	}
 
+	if !shouldRefreshCA(caFile) {
+		return nil
+	}
+
 	// Create insecure client to download CA cert
 	client := &http.Client{
 		Transport: &http.Transport{
@@ -113,6 +146,10 @@ func (r *Registrar) readServerCACert() error {
 		return err
 	}
 
+	if err := VerifySPKIPin(caCert); err != nil {
+		return fmt.Errorf("refusing to trust downloaded server CA: %w", err)
+	}
+
 	// Ensure config directory exists
 	if err := os.MkdirAll(filepath.Dir(caFile), 0755); err != nil {
 		return err
@@ -175,11 +212,11 @@ func (r *Registrar) requestToken() error {
 }
 
 // registerAndGetCerts registers with the server and downloads agent certificates
-func (r *Registrar) registerAndGetCerts() error {
+func (r *Registrar) registerAndGetCerts(ctx context.Context) error {
 	// For HTTP servers, certificates are not used or returned
 	if r.config.ServerURL.Scheme == "http" {
 		log.Println("HTTP server - skipping certificate retrieval")
-		return r.registerHTTP()
+		return r.registerHTTP(ctx)
 	}
 
 	// HTTPS flow - get certificates
@@ -194,11 +231,11 @@ func (r *Registrar) registerAndGetCerts() error {
 		return nil
 	}
 
-	return r.registerAndDownloadCerts()
+	return r.registerAndDownloadCerts(ctx)
 }
 
 // registerHTTP performs basic registration for HTTP servers (no certificates)
-func (r *Registrar) registerHTTP() error {
+func (r *Registrar) registerHTTP(ctx context.Context) error {
 	// Read token
 	token, err := os.ReadFile(r.config.AgentTokenFile())
 	if err != nil {
@@ -206,7 +243,10 @@ func (r *Registrar) registerHTTP() error {
 	}
 
 	// Prepare registration form data
-	formData := r.registrationData()
+	formData, err := r.enrichedRegistrationData(ctx)
+	if err != nil {
+		return fmt.Errorf("registration enrichment failed: %w", err)
+	}
 	formData.Set("token", string(token))
 
 	// Register with server
@@ -227,17 +267,36 @@ func (r *Registrar) registerHTTP() error {
 	return nil
 }
 
-// registerAndDownloadCerts performs HTTPS registration and downloads certificates
-func (r *Registrar) registerAndDownloadCerts() error {
+// registerAndDownloadCerts performs HTTPS registration via CSR-based enrollment: the agent
+// generates its own private key, keeps it on disk, and only ever sends the server a CSR.
+// The server is expected to respond with a signed CertificateChain. For servers that still
+// implement the legacy flow (returning AgentPrivateKey), we fall back to honoring it so older
+// GoCD servers keep working, but log a warning since that defeats the point of CSR enrollment.
+func (r *Registrar) registerAndDownloadCerts(ctx context.Context) error {
 	// Read token
 	token, err := os.ReadFile(r.config.AgentTokenFile())
 	if err != nil {
 		return fmt.Errorf("failed to read token: %w", err)
 	}
 
+	privateKeyFile := r.config.AgentPrivateKeyFile()
+	key, err := generateOrLoadPrivateKey(privateKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to prepare agent private key: %w", err)
+	}
+
+	csrPEM, err := buildCSR(r.config, key)
+	if err != nil {
+		return fmt.Errorf("failed to build CSR: %w", err)
+	}
+
 	// Prepare registration form data
-	formData := r.registrationData()
+	formData, err := r.enrichedRegistrationData(ctx)
+	if err != nil {
+		return fmt.Errorf("registration enrichment failed: %w", err)
+	}
 	formData.Set("token", string(token))
+	formData.Set("csr", string(csrPEM))
 
 	// Register with server
 	registrationURL := r.config.RegistrationURL()
@@ -270,19 +329,24 @@ func (r *Registrar) registerAndDownloadCerts() error {
 		return fmt.Errorf("failed to decode registration response (got %d bytes): %w", len(bodyBytes), err)
 	}
 
-	if registration.AgentCertificate == "" {
+	chain := registration.CertificateChain
+	if chain == "" {
+		chain = registration.AgentCertificate
+	}
+	if chain == "" {
 		return fmt.Errorf("registration failed: empty certificate (agent may need approval on server)")
 	}
 
-	// Save private key and certificate
-	privateKeyFile := r.config.AgentPrivateKeyFile()
-	certFile := r.config.AgentCertFile()
-
-	if err := os.WriteFile(privateKeyFile, []byte(registration.AgentPrivateKey), 0600); err != nil {
-		return fmt.Errorf("failed to write private key: %w", err)
+	// Legacy fallback: the server generated and returned our private key itself.
+	if registration.AgentPrivateKey != "" {
+		log.Println("WARNING: server returned agentPrivateKey; falling back to legacy enrollment (the agent's private key was transmitted by the server instead of staying local)")
+		if err := os.WriteFile(privateKeyFile, []byte(registration.AgentPrivateKey), 0600); err != nil {
+			return fmt.Errorf("failed to write private key: %w", err)
+		}
 	}
 
-	if err := os.WriteFile(certFile, []byte(registration.AgentCertificate), 0600); err != nil {
+	certFile := r.config.AgentCertFile()
+	if err := os.WriteFile(certFile, []byte(chain), 0600); err != nil {
 		return fmt.Errorf("failed to write certificate: %w", err)
 	}
 
@@ -290,6 +354,205 @@ func (r *Registrar) registerAndDownloadCerts() error {
 	return nil
 }
 
+// RenewCertificate re-enrolls using the agent's existing private key (the same CSR flow as
+// initial registration) and atomically replaces the on-disk certificate, so a concurrent
+// reader (e.g. the WebSocket transport reloading its TLS config) never observes a partially
+// written file. Intended to be called from Renewer before the current certificate expires.
+func (r *Registrar) RenewCertificate() error {
+	token, err := os.ReadFile(r.config.AgentTokenFile())
+	if err != nil {
+		return fmt.Errorf("failed to read token: %w", err)
+	}
+
+	key, err := generateOrLoadPrivateKey(r.config.AgentPrivateKeyFile())
+	if err != nil {
+		return fmt.Errorf("failed to load agent private key: %w", err)
+	}
+	csrPEM, err := buildCSR(r.config, key)
+	if err != nil {
+		return fmt.Errorf("failed to build CSR: %w", err)
+	}
+
+	formData := r.registrationData()
+	formData.Set("token", string(token))
+	formData.Set("csr", string(csrPEM))
+
+	registrationURL := r.config.RegistrationURL()
+	resp, err := r.httpClient.PostForm(registrationURL, formData)
+	if err != nil {
+		return fmt.Errorf("failed to POST renewal to %s: %w", registrationURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("renewal failed: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read renewal response body: %w", err)
+	}
+
+	var registration protocol.Registration
+	if err := json.Unmarshal(bodyBytes, &registration); err != nil {
+		return fmt.Errorf("failed to decode renewal response (got %d bytes): %w", len(bodyBytes), err)
+	}
+
+	chain := registration.CertificateChain
+	if chain == "" {
+		chain = registration.AgentCertificate
+	}
+	if chain == "" {
+		return fmt.Errorf("renewal failed: empty certificate in response")
+	}
+
+	if strings.EqualFold(os.Getenv("EX_GOCD_AGENT_CERT_OCSP_CHECK"), "true") {
+		if err := verifyOCSPForChain([]byte(chain), r.httpClient); err != nil {
+			return fmt.Errorf("renewed certificate failed OCSP check: %w", err)
+		}
+	}
+
+	return writeFileAtomic(r.config.AgentCertFile(), []byte(chain), 0600)
+}
+
+// verifyOCSPForChain checks the leaf certificate of a PEM chain against its issuer (the next
+// certificate in the chain) by querying its OCSP responder (see VerifyOCSP). Requires at least two PEM blocks (leaf +
+// issuer); a single-certificate chain has no issuer to check against and is accepted as-is.
+func verifyOCSPForChain(chainPEM []byte, httpClient *http.Client) error {
+	var certs []*x509.Certificate
+	rest := chainPEM
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("parse certificate in chain: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) < 2 {
+		return nil
+	}
+	return VerifyOCSP(certs[0], certs[1], httpClient)
+}
+
+// writeFileAtomic writes data to a tempfile in the same directory as path, then renames it
+// over path so readers never see a truncated or half-written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once the rename below succeeds
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// generateOrLoadPrivateKey returns the agent's existing private key if privateKeyFile is
+// already present, otherwise generates a new one (ECDSA P-256 by default; set
+// EX_GOCD_AGENT_KEY_TYPE=rsa-2048 for RSA) and writes it to privateKeyFile with mode 0600.
+// The key is never sent to the server - only a CSR derived from it is (see buildCSR).
+func generateOrLoadPrivateKey(privateKeyFile string) (crypto.Signer, error) {
+	if data, err := os.ReadFile(privateKeyFile); err == nil {
+		return parsePrivateKeyPEM(data)
+	}
+
+	var key crypto.Signer
+	var der []byte
+	var err error
+	if strings.EqualFold(os.Getenv("EX_GOCD_AGENT_KEY_TYPE"), keyTypeRSA) {
+		rsaKey, genErr := rsa.GenerateKey(rand.Reader, 2048)
+		if genErr != nil {
+			return nil, fmt.Errorf("generate RSA key: %w", genErr)
+		}
+		key = rsaKey
+		der, err = x509.MarshalPKCS8PrivateKey(rsaKey)
+	} else {
+		ecKey, genErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if genErr != nil {
+			return nil, fmt.Errorf("generate ECDSA key: %w", genErr)
+		}
+		key = ecKey
+		der, err = x509.MarshalPKCS8PrivateKey(ecKey)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshal private key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(privateKeyFile), 0755); err != nil {
+		return nil, err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(privateKeyFile, pemBytes, 0600); err != nil {
+		return nil, fmt.Errorf("write private key: %w", err)
+	}
+	return key, nil
+}
+
+// parsePrivateKeyPEM decodes a PEM-encoded private key, accepting PKCS#8 (our own format)
+// as well as the legacy PKCS#1/SEC1 encodings a server might have returned historically.
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM private key")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("private key does not support signing")
+		}
+		return signer, nil
+	}
+	if rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return rsaKey, nil
+	}
+	if ecKey, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return ecKey, nil
+	}
+	return nil, fmt.Errorf("unrecognized private key encoding in %s", block.Type)
+}
+
+// buildCSR builds a PKCS#10 certificate signing request for this agent: CommonName is the
+// agent UUID (so the server can bind the issued cert to this registration) and the SANs
+// cover the agent's hostname and detected IP address.
+func buildCSR(cfg *config.Config, key crypto.Signer) ([]byte, error) {
+	template := x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: cfg.UUID},
+		DNSNames:    []string{cfg.Hostname},
+		IPAddresses: parseIPAddresses(cfg.IPAddress),
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &template, key)
+	if err != nil {
+		return nil, fmt.Errorf("create CSR: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), nil
+}
+
+func parseIPAddresses(s string) []net.IP {
+	if ip := net.ParseIP(s); ip != nil {
+		return []net.IP{ip}
+	}
+	return nil
+}
+
 // registrationData prepares the form data for registration
 func (r *Registrar) registrationData() url.Values {
 	cfg := r.config
@@ -311,6 +574,17 @@ func (r *Registrar) registrationData() url.Values {
 	}
 }
 
+// enrichedRegistrationData builds the registration form and, if a RegistrationEnricher is
+// configured (EX_GOCD_REGISTRATION_WEBHOOK_URL), lets it merge in resources/environments/
+// extra fields sourced from a CMDB or cloud metadata service before the token is attached.
+func (r *Registrar) enrichedRegistrationData(ctx context.Context) (url.Values, error) {
+	data := r.registrationData()
+	if r.enricher == nil {
+		return data, nil
+	}
+	return r.enricher.Enrich(ctx, data)
+}
+
 // createHTTPClient creates an HTTP client with TLS configuration
 func (r *Registrar) createHTTPClient(withClientCert bool) (*http.Client, error) {
 	tlsConfig, err := r.createTLSConfig(withClientCert)
@@ -325,22 +599,20 @@ func (r *Registrar) createHTTPClient(withClientCert bool) (*http.Client, error)
 	}, nil
 }
 
-// createTLSConfig creates TLS configuration for client
+// createTLSConfig creates TLS configuration for client. The CA bundle (server CA plus any
+// ExtraCAFileList entries) and client certificate are loaded via tlsconfig.Load, so the
+// registrar's own HTTP client (createHTTPClient) and this agent's WebSocket/gRPC connections trust
+// the same CAs and present the same client identity. The remoting get_work client is not built
+// from it: Agent.runRemoting passes remoting.NewClient the agent's httpClient.
 func (r *Registrar) createTLSConfig(withClientCert bool) (*tls.Config, error) {
 	// If using HTTP (not HTTPS), return nil - no TLS needed
 	if r.config.ServerURL.Scheme != "https" && r.config.ServerURL.Scheme != "wss" {
 		return nil, nil
 	}
 
-	// Load server CA certificate
-	caCert, err := os.ReadFile(r.config.GoServerCAFile())
+	tlsConfig, err := tlsconfig.Load(r.config, withClientCert)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CA cert: %w", err)
-	}
-
-	roots := x509.NewCertPool()
-	if !roots.AppendCertsFromPEM(caCert) {
-		return nil, fmt.Errorf("failed to parse CA certificate")
+		return nil, err
 	}
 
 	// Extract server DN for verification
@@ -348,23 +620,10 @@ func (r *Registrar) createTLSConfig(withClientCert bool) (*tls.Config, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	tlsConfig := &tls.Config{
-		RootCAs:    roots,
-		ServerName: serverName,
-	}
-
-	// Add client certificate if requested
-	if withClientCert {
-		cert, err := tls.LoadX509KeyPair(
-			r.config.AgentCertFile(),
-			r.config.AgentPrivateKeyFile(),
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load client cert: %w", err)
-		}
-		tlsConfig.Certificates = []tls.Certificate{cert}
-	}
+	tlsConfig.ServerName = serverName
+	// No-op until the TrustStore has a CRL loaded (first periodic refresh, or a cached one
+	// from disk); once it does, this rejects handshakes with a revoked server cert.
+	tlsConfig.VerifyPeerCertificate = r.trustStore.VerifyPeerCertificate()
 
 	return tlsConfig, nil
 }
@@ -396,37 +655,39 @@ func usableSpace() int64 {
 	return 10 * 1024 * 1024 * 1024
 }
 
-// registerWithRetry attempts registration with exponential backoff for approval
-func (r *Registrar) registerWithRetry() error {
+// registrationBackoff is the shared retry schedule for pending-approval registration: matches
+// the agent's former hardcoded 2s/4s/8s/16s schedule, now routed through ctx cancellation.
+var registrationBackoff = backoff.Strategy{Base: 2 * time.Second, Max: 32 * time.Second, Jitter: backoff.NoJitter}
+
+const maxRegistrationAttempts = 5
+
+// registerWithRetry attempts registration with exponential backoff for approval. The wait
+// between attempts honors ctx, so shutdown is no longer blocked behind a pending time.Sleep.
+func (r *Registrar) registerWithRetry(ctx context.Context) error {
 	// For HTTP servers, empty responses are normal - no retry needed
 	if r.config.ServerURL.Scheme == "http" {
-		return r.registerAndGetCerts()
+		return r.registerAndGetCerts(ctx)
 	}
 
 	// For HTTPS servers, retry if agent approval is pending
-	maxRetries := 5
-	baseDelay := 2 * time.Second
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		err := r.registerAndGetCerts()
-		if err == nil {
-			return nil
+	attempt := 0
+	err := registrationBackoff.Do(ctx, func() error {
+		attempt++
+		return r.registerAndGetCerts(ctx)
+	}, func(err error) bool {
+		if attempt >= maxRegistrationAttempts {
+			return false
 		}
-
-		// Check if it's a pending approval error
 		if strings.Contains(err.Error(), "pending") || strings.Contains(err.Error(), "empty") {
-			if attempt < maxRetries-1 {
-				delay := baseDelay * time.Duration(1<<uint(attempt)) // Exponential backoff
-				log.Printf("Agent pending approval, retrying in %v (attempt %d/%d)...", delay, attempt+1, maxRetries)
-				time.Sleep(delay)
-				continue
-			}
+			log.Printf("Agent pending approval, retrying (attempt %d/%d)...", attempt, maxRegistrationAttempts)
+			return true
 		}
-
-		return err
+		return false
+	})
+	if err != nil && attempt >= maxRegistrationAttempts {
+		return fmt.Errorf("registration failed after %d attempts: %w", maxRegistrationAttempts, err)
 	}
-
-	return fmt.Errorf("registration failed after %d attempts", maxRetries)
+	return err
 }
 
 // CreateTLSConfig creates a TLS config for WebSocket connection
@@ -0,0 +1,144 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package registration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) *config.Config {
+	serverURL, err := url.Parse("https://gocd.example:8154/go")
+	require.NoError(t, err)
+	return &config.Config{
+		ServerURL: serverURL,
+		ConfigDir: t.TempDir(),
+		Hostname:  "agent-host",
+		IPAddress: "10.0.0.5",
+		UUID:      "11111111-1111-1111-1111-111111111111",
+	}
+}
+
+func TestGenerateOrLoadPrivateKey_DefaultIsECDSA(t *testing.T) {
+	cfg := testConfig(t)
+
+	key, err := generateOrLoadPrivateKey(cfg.AgentPrivateKeyFile())
+	require.NoError(t, err)
+	_, ok := key.Public().(*ecdsa.PublicKey)
+	assert.True(t, ok, "default key type should be ECDSA P-256")
+
+	info, err := os.Stat(cfg.AgentPrivateKeyFile())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Loading again must return the persisted key, not a freshly generated one.
+	reloaded, err := generateOrLoadPrivateKey(cfg.AgentPrivateKeyFile())
+	require.NoError(t, err)
+	assert.Equal(t, key.Public(), reloaded.Public())
+}
+
+func TestGenerateOrLoadPrivateKey_RSAViaEnv(t *testing.T) {
+	t.Setenv("EX_GOCD_AGENT_KEY_TYPE", keyTypeRSA)
+	cfg := testConfig(t)
+
+	key, err := generateOrLoadPrivateKey(cfg.AgentPrivateKeyFile())
+	require.NoError(t, err)
+	rsaKey, ok := key.Public().(*rsa.PublicKey)
+	require.True(t, ok, "EX_GOCD_AGENT_KEY_TYPE=rsa-2048 should generate an RSA key")
+	assert.Equal(t, 2048, rsaKey.N.BitLen())
+}
+
+func TestBuildCSR_SubjectAndSANs(t *testing.T) {
+	cfg := testConfig(t)
+	key, err := generateOrLoadPrivateKey(cfg.AgentPrivateKeyFile())
+	require.NoError(t, err)
+
+	csrPEM, err := buildCSR(cfg, key)
+	require.NoError(t, err)
+
+	block, _ := pem.Decode(csrPEM)
+	require.NotNil(t, block)
+	assert.Equal(t, "CERTIFICATE REQUEST", block.Type)
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(t, err)
+	require.NoError(t, csr.CheckSignature())
+	assert.Equal(t, cfg.UUID, csr.Subject.CommonName)
+	assert.Contains(t, csr.DNSNames, cfg.Hostname)
+	require.Len(t, csr.IPAddresses, 1)
+	assert.Equal(t, cfg.IPAddress, csr.IPAddresses[0].String())
+}
+
+// TestCSREnrollment_RoundTripThroughTestCA exercises the full enrollment path: the agent
+// generates a key and CSR, a test CA signs it into a chain, the chain is written to
+// AgentCertFile, and the resulting keypair loads via createTLSConfig(true) - the same path
+// used to build the WebSocket client certificate.
+func TestCSREnrollment_RoundTripThroughTestCA(t *testing.T) {
+	cfg := testConfig(t)
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	require.NoError(t, os.WriteFile(cfg.GoServerCAFile(), caPEM, 0644))
+
+	key, err := generateOrLoadPrivateKey(cfg.AgentPrivateKeyFile())
+	require.NoError(t, err)
+	csrPEM, err := buildCSR(cfg, key)
+	require.NoError(t, err)
+	block, _ := pem.Decode(csrPEM)
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	require.NoError(t, err)
+
+	agentTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      csr.Subject,
+		DNSNames:     csr.DNSNames,
+		IPAddresses:  csr.IPAddresses,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	agentDER, err := x509.CreateCertificate(rand.Reader, agentTemplate, caCert, csr.PublicKey, caKey)
+	require.NoError(t, err)
+	chainPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: agentDER})
+	chainPEM = append(chainPEM, caPEM...)
+	require.NoError(t, os.WriteFile(cfg.AgentCertFile(), chainPEM, 0600))
+
+	r := New(cfg)
+	tlsConfig, err := r.createTLSConfig(true)
+	require.NoError(t, err)
+	require.Len(t, tlsConfig.Certificates, 1)
+
+	leaf, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UUID, leaf.Subject.CommonName)
+}
@@ -0,0 +1,147 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Background certificate rotation: renews the agent's client cert before it expires.
+
+package registration
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	minCheckInterval = time.Minute
+	maxCheckInterval = time.Hour
+)
+
+// Renewer periodically checks the agent's client certificate (AgentCertFile) and renews it,
+// via the CSR flow, before it expires. A renewal threshold of max(renewBefore, lifetime/3)
+// follows the smallstep/cashier two-thirds rule; both the floor and the jitter can be tuned
+// with EX_GOCD_CERT_RENEW_BEFORE and EX_GOCD_CERT_RENEW_JITTER.
+type Renewer struct {
+	registrar *Registrar
+	reloadFn  func() error
+}
+
+// NewRenewer creates a Renewer bound to registrar. reloadFn is called after a successful
+// renewal so the caller can hot-swap its TLS config (e.g. Agent.Reload); it may be nil.
+func NewRenewer(registrar *Registrar, reloadFn func() error) *Renewer {
+	return &Renewer{registrar: registrar, reloadFn: reloadFn}
+}
+
+// Start runs the renewal loop until ctx is cancelled. Meant to be launched in its own
+// goroutine from cmd.runAgent, after agent.New.
+func (rw *Renewer) Start(ctx context.Context) {
+	for {
+		checkIn := rw.nextCheckIn()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(fullJitter(checkIn)):
+		}
+
+		remaining, lifetime, err := certRemainingAndLifetime(rw.registrar.config.AgentCertFile())
+		if err != nil {
+			log.Printf("cert renewal: could not read agent certificate: %v", err)
+			continue
+		}
+		if remaining >= renewThreshold(lifetime) {
+			continue
+		}
+
+		log.Printf("agent certificate expires in %v (lifetime %v); renewing", remaining, lifetime)
+		if err := rw.registrar.RenewCertificate(); err != nil {
+			log.Printf("cert renewal failed: %v", err)
+			continue
+		}
+		if rw.reloadFn != nil {
+			if err := rw.reloadFn(); err != nil {
+				log.Printf("cert renewal: reload failed: %v", err)
+			}
+		}
+	}
+}
+
+// nextCheckIn returns how long to sleep before the next expiry check: min(remaining/2, 1h),
+// clamped to a sane floor so a misread certificate can't spin the loop.
+func (rw *Renewer) nextCheckIn() time.Duration {
+	remaining, _, err := certRemainingAndLifetime(rw.registrar.config.AgentCertFile())
+	if err != nil {
+		log.Printf("cert renewal: could not read agent certificate: %v", err)
+		return minCheckInterval
+	}
+	interval := remaining / 2
+	if interval > maxCheckInterval {
+		interval = maxCheckInterval
+	}
+	if interval < minCheckInterval {
+		interval = minCheckInterval
+	}
+	return interval
+}
+
+// certRemainingAndLifetime parses the leaf certificate in certFile and returns the time
+// remaining until NotAfter and the certificate's total lifetime (NotAfter - NotBefore).
+func certRemainingAndLifetime(certFile string) (remaining, lifetime time.Duration, err error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return 0, 0, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return 0, 0, fmt.Errorf("no PEM block in %s", certFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parse certificate: %w", err)
+	}
+	return time.Until(cert.NotAfter), cert.NotAfter.Sub(cert.NotBefore), nil
+}
+
+// renewThreshold implements max(renewBefore, lifetime/3): the two-thirds rule by default,
+// with an operator-configurable absolute floor.
+func renewThreshold(lifetime time.Duration) time.Duration {
+	threshold := lifetime / 3
+	if before := renewBeforeOverride(); before > threshold {
+		threshold = before
+	}
+	return threshold
+}
+
+// renewBeforeOverride reads EX_GOCD_CERT_RENEW_BEFORE (a time.ParseDuration string, e.g.
+// "24h"). Returns 0 if unset or invalid, meaning the two-thirds rule alone applies.
+func renewBeforeOverride() time.Duration {
+	v := os.Getenv("EX_GOCD_CERT_RENEW_BEFORE")
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("cert renewal: invalid EX_GOCD_CERT_RENEW_BEFORE %q: %v", v, err)
+		return 0
+	}
+	return d
+}
+
+// jitterEnabled reports whether full-jitter sleeps are applied to the renewal check loop.
+// Set EX_GOCD_CERT_RENEW_JITTER=false to disable (e.g. in deterministic tests); enabled by
+// default to avoid synchronized renewal storms across a fleet of agents.
+func jitterEnabled() bool {
+	v := strings.ToLower(os.Getenv("EX_GOCD_CERT_RENEW_JITTER"))
+	return v != "false" && v != "0"
+}
+
+// fullJitter implements AWS-style full jitter: a uniform random duration in [0, d).
+func fullJitter(d time.Duration) time.Duration {
+	if !jitterEnabled() || d <= 0 {
+		return d
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
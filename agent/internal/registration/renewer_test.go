@@ -0,0 +1,91 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package registration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCert writes a self-signed cert valid for lifetime, started notBeforeAgo in the
+// past, and returns its path.
+func writeTestCert(t *testing.T, lifetime, notBeforeAgo time.Duration) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	notBefore := time.Now().Add(-notBeforeAgo)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "agent-under-test"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(lifetime),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := t.TempDir() + "/agent-cert.pem"
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	return path
+}
+
+func TestCertRemainingAndLifetime_ShortLivedCert(t *testing.T) {
+	certFile := writeTestCert(t, 30*time.Second, 0)
+
+	remaining, lifetime, err := certRemainingAndLifetime(certFile)
+	require.NoError(t, err)
+	assert.InDelta(t, 30*time.Second, remaining, float64(2*time.Second))
+	assert.Equal(t, 30*time.Second, lifetime)
+}
+
+func TestRenewThreshold_TwoThirdsRuleByDefault(t *testing.T) {
+	t.Setenv("EX_GOCD_CERT_RENEW_BEFORE", "")
+	assert.Equal(t, 10*time.Second, renewThreshold(30*time.Second))
+}
+
+func TestRenewThreshold_AbsoluteFloorOverridesTwoThirdsRule(t *testing.T) {
+	t.Setenv("EX_GOCD_CERT_RENEW_BEFORE", "20s")
+	assert.Equal(t, 20*time.Second, renewThreshold(30*time.Second))
+}
+
+// TestShouldRenew_BoundaryOnShortLivedCert exercises the trigger boundary on a 30s test CA
+// cert: once less than a third of its lifetime remains, renewal should be due.
+func TestShouldRenew_BoundaryOnShortLivedCert(t *testing.T) {
+	t.Setenv("EX_GOCD_CERT_RENEW_BEFORE", "")
+
+	// 21s old out of 30s lifetime: 9s remaining < 10s threshold -> due for renewal.
+	dueCert := writeTestCert(t, 30*time.Second, 21*time.Second)
+	remaining, lifetime, err := certRemainingAndLifetime(dueCert)
+	require.NoError(t, err)
+	assert.True(t, remaining < renewThreshold(lifetime))
+
+	// 5s old out of 30s lifetime: 25s remaining >= 10s threshold -> not due yet.
+	freshCert := writeTestCert(t, 30*time.Second, 5*time.Second)
+	remaining, lifetime, err = certRemainingAndLifetime(freshCert)
+	require.NoError(t, err)
+	assert.False(t, remaining < renewThreshold(lifetime))
+}
+
+func TestFullJitter_StaysWithinBounds(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		d := fullJitter(10 * time.Second)
+		assert.True(t, d >= 0 && d <= 10*time.Second)
+	}
+}
+
+func TestFullJitter_DisabledReturnsInputUnchanged(t *testing.T) {
+	t.Setenv("EX_GOCD_CERT_RENEW_JITTER", "false")
+	assert.Equal(t, 7*time.Second, fullJitter(7*time.Second))
+}
@@ -0,0 +1,263 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// TrustStore: SPKI pinning for the TOFU-downloaded server CA, periodic CRL refresh consulted
+// during the TLS handshake, and OCSP verification of the agent's own certificate on renewal.
+
+package registration
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+	"golang.org/x/crypto/ocsp"
+)
+
+const defaultCRLRefreshInterval = 6 * time.Hour
+
+// TrustStore guards how the agent trusts the GoCD server's CA beyond plain TOFU: an
+// optional SPKI pin checked before the CA file is ever written, and a periodically
+// refreshed CRL consulted on every TLS handshake via VerifyPeerCertificate.
+type TrustStore struct {
+	cfg        *config.Config
+	httpClient *http.Client
+
+	crlMu sync.RWMutex
+	crl   *x509.RevocationList
+}
+
+// NewTrustStore creates a TrustStore for cfg. httpClient is used for CRL downloads.
+func NewTrustStore(cfg *config.Config, httpClient *http.Client) *TrustStore {
+	return &TrustStore{cfg: cfg, httpClient: httpClient}
+}
+
+// VerifySPKIPin checks the SubjectPublicKeyInfo of a PEM-encoded certificate against
+// EX_GOCD_SERVER_CA_SPKI_PIN (base64 SHA-256 of the SPKI, à la HPKP). Returns nil if no pin
+// is configured - pinning is opt-in.
+func VerifySPKIPin(caPEM []byte) error {
+	pin := os.Getenv("EX_GOCD_SERVER_CA_SPKI_PIN")
+	if pin == "" {
+		return nil
+	}
+	got, err := spkiPin(caPEM)
+	if err != nil {
+		return err
+	}
+	if got != pin {
+		return fmt.Errorf("server CA SPKI pin mismatch: got %s, want %s", got, pin)
+	}
+	return nil
+}
+
+func spkiPin(caPEM []byte) (string, error) {
+	block, _ := pem.Decode(caPEM)
+	if block == nil {
+		return "", fmt.Errorf("failed to decode PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// VerifyPeerCertificate returns a tls.Config.VerifyPeerCertificate callback that rejects the
+// leaf certificate if it appears on the most recently refreshed CRL. With no CRL loaded yet
+// (not refreshed, or the CA publishes none) it's a no-op so normal chain verification alone
+// governs the handshake.
+func (ts *TrustStore) VerifyPeerCertificate() func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		crl := ts.currentCRL()
+		if crl == nil || len(rawCerts) == 0 {
+			return nil
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return nil // Chain verification will surface the parse failure on its own.
+		}
+		for _, revoked := range crl.RevokedCertificateEntries {
+			if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return fmt.Errorf("certificate serial %s is revoked (CRL)", cert.SerialNumber)
+			}
+		}
+		return nil
+	}
+}
+
+// RefreshCRL downloads the CRL named by EX_GOCD_SERVER_CA_CRL_URL, falling back to the CA
+// certificate's own crlDistributionPoints extension. It's a no-op (not an error) if neither
+// source is available - CRL checking is best-effort, layered on top of normal chain trust.
+func (ts *TrustStore) RefreshCRL(ctx context.Context, caCert *x509.Certificate) error {
+	crlURL := os.Getenv("EX_GOCD_SERVER_CA_CRL_URL")
+	if crlURL == "" {
+		if len(caCert.CRLDistributionPoints) == 0 {
+			return nil
+		}
+		crlURL = caCert.CRLDistributionPoints[0]
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, crlURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ts.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("download CRL from %s: %w", crlURL, err)
+	}
+	defer resp.Body.Close()
+
+	der, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return fmt.Errorf("parse CRL: %w", err)
+	}
+
+	if err := os.WriteFile(ts.crlCacheFile(), der, 0644); err != nil {
+		log.Printf("trust store: failed to cache CRL: %v", err)
+	}
+
+	ts.crlMu.Lock()
+	ts.crl = crl
+	ts.crlMu.Unlock()
+	return nil
+}
+
+// StartPeriodicRefresh refreshes the CRL from the cached server CA file on a ticker (default
+// 6h, override with EX_GOCD_SERVER_CA_CRL_REFRESH_INTERVAL) until ctx is cancelled. Meant to
+// be launched in its own goroutine from cmd.runAgent alongside the certificate Renewer.
+func (ts *TrustStore) StartPeriodicRefresh(ctx context.Context) {
+	ticker := time.NewTicker(crlRefreshInterval())
+	defer ticker.Stop()
+	for {
+		if err := ts.refreshFromCachedCA(ctx); err != nil {
+			log.Printf("trust store: CRL refresh failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (ts *TrustStore) refreshFromCachedCA(ctx context.Context) error {
+	data, err := os.ReadFile(ts.cfg.GoServerCAFile())
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("no PEM block in %s", ts.cfg.GoServerCAFile())
+	}
+	caCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	return ts.RefreshCRL(ctx, caCert)
+}
+
+func crlRefreshInterval() time.Duration {
+	if v := os.Getenv("EX_GOCD_SERVER_CA_CRL_REFRESH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return defaultCRLRefreshInterval
+}
+
+func (ts *TrustStore) crlCacheFile() string {
+	return filepath.Join(ts.cfg.ConfigDir, "server-ca.crl")
+}
+
+// currentCRL returns the in-memory CRL, loading the on-disk cache the first time if this
+// process hasn't refreshed yet.
+func (ts *TrustStore) currentCRL() *x509.RevocationList {
+	ts.crlMu.RLock()
+	crl := ts.crl
+	ts.crlMu.RUnlock()
+	if crl != nil {
+		return crl
+	}
+
+	data, err := os.ReadFile(ts.crlCacheFile())
+	if err != nil {
+		return nil
+	}
+	cached, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil
+	}
+	return cached
+}
+
+// VerifyOCSP checks leaf's revocation status against issuer by querying the OCSP responder
+// whose URL is embedded in leaf's AuthorityInfoAccess extension. Returns nil if leaf has no
+// configured OCSP responder - there's nothing to check.
+func VerifyOCSP(leaf, issuer *x509.Certificate, httpClient *http.Client) error {
+	if len(leaf.OCSPServer) == 0 {
+		return nil
+	}
+
+	reqDER, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return fmt.Errorf("create OCSP request: %w", err)
+	}
+
+	resp, err := httpClient.Post(leaf.OCSPServer[0], "application/ocsp-request", bytes.NewReader(reqDER))
+	if err != nil {
+		return fmt.Errorf("OCSP request to %s: %w", leaf.OCSPServer[0], err)
+	}
+	defer resp.Body.Close()
+
+	respDER, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	ocspResp, err := ocsp.ParseResponseForCert(respDER, leaf, issuer)
+	if err != nil {
+		return fmt.Errorf("parse OCSP response: %w", err)
+	}
+	if ocspResp.Status != ocsp.Good {
+		return fmt.Errorf("OCSP status for certificate serial %s is not good: %d", leaf.SerialNumber, ocspResp.Status)
+	}
+	return nil
+}
+
+// shouldRefreshCA reports whether the cached server CA needs a (re)download: missing,
+// unparseable, explicitly forced via EX_GOCD_SERVER_CA_REFRESH=true, or within 30 days of
+// expiry.
+func shouldRefreshCA(caFile string) bool {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return true
+	}
+	if strings.EqualFold(os.Getenv("EX_GOCD_SERVER_CA_REFRESH"), "true") {
+		return true
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return true
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+	return time.Until(cert.NotAfter) < 30*24*time.Hour
+}
@@ -0,0 +1,285 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package registration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA is an in-memory CA used by the trust store tests to issue a leaf certificate and,
+// later, a CRL revoking it.
+type testCA struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+	der  []byte
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{
+		key:  key,
+		cert: cert,
+		der:  der,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issueLeaf issues a server-auth leaf certificate for 127.0.0.1, signed by ca.
+func (ca *testCA) issueLeaf(t *testing.T, serial int64) (tls.Certificate, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	tlsCert := tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+	return tlsCert, cert
+}
+
+// crl builds a CRL (signed by ca) revoking the given serial numbers.
+func (ca *testCA) crl(t *testing.T, revoked ...*big.Int) []byte {
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, serial := range revoked {
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: time.Now(),
+		})
+	}
+	template := &x509.RevocationList{
+		Number:                    big.NewInt(1),
+		ThisUpdate:                time.Now().Add(-time.Minute),
+		NextUpdate:                time.Now().Add(time.Hour),
+		RevokedCertificateEntries: entries,
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca.cert, ca.key)
+	require.NoError(t, err)
+	return der
+}
+
+func TestVerifySPKIPin_NoOpWhenUnconfigured(t *testing.T) {
+	ca := newTestCA(t)
+	assert.NoError(t, VerifySPKIPin(ca.pem))
+}
+
+func TestVerifySPKIPin_MatchesConfiguredPin(t *testing.T) {
+	ca := newTestCA(t)
+	sum := sha256.Sum256(ca.cert.RawSubjectPublicKeyInfo)
+	t.Setenv("EX_GOCD_SERVER_CA_SPKI_PIN", base64.StdEncoding.EncodeToString(sum[:]))
+
+	assert.NoError(t, VerifySPKIPin(ca.pem))
+}
+
+func TestVerifySPKIPin_RejectsMismatchedPin(t *testing.T) {
+	ca := newTestCA(t)
+	t.Setenv("EX_GOCD_SERVER_CA_SPKI_PIN", "not-the-right-hash")
+
+	err := VerifySPKIPin(ca.pem)
+	assert.Error(t, err)
+}
+
+func TestVerifyPeerCertificate_RejectsRevokedSerial(t *testing.T) {
+	ca := newTestCA(t)
+	leafTLS, leafCert := ca.issueLeaf(t, 2)
+	crlDER := ca.crl(t, leafCert.SerialNumber)
+
+	ts := &TrustStore{cfg: testConfig(t)}
+	require.NoError(t, os.WriteFile(ts.crlCacheFile(), crlDER, 0644))
+
+	err := ts.VerifyPeerCertificate()(leafTLS.Certificate, nil)
+	assert.Error(t, err)
+}
+
+func TestVerifyPeerCertificate_AllowsNonRevokedSerial(t *testing.T) {
+	ca := newTestCA(t)
+	leafTLS, _ := ca.issueLeaf(t, 2)
+	// CRL revokes a different serial, so our leaf should pass.
+	crlDER := ca.crl(t, big.NewInt(999))
+
+	ts := &TrustStore{cfg: testConfig(t)}
+	require.NoError(t, os.WriteFile(ts.crlCacheFile(), crlDER, 0644))
+
+	err := ts.VerifyPeerCertificate()(leafTLS.Certificate, nil)
+	assert.NoError(t, err)
+}
+
+func TestVerifyPeerCertificate_NoOpWithoutCachedCRL(t *testing.T) {
+	ca := newTestCA(t)
+	leafTLS, _ := ca.issueLeaf(t, 2)
+
+	ts := &TrustStore{cfg: testConfig(t)}
+	err := ts.VerifyPeerCertificate()(leafTLS.Certificate, nil)
+	assert.NoError(t, err)
+}
+
+// serveHandshake accepts a single connection on listener and drives the server side of the TLS
+// handshake to completion before closing it. tls.Listen's Accept returns before any handshake
+// bytes are exchanged (the handshake is lazy), so closing the raw connection without this would
+// race the client's tls.Dial and surface as a spurious EOF instead of a real verify result.
+func serveHandshake(listener net.Listener) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		_ = tlsConn.Handshake()
+	}
+}
+
+// TestTLSHandshake_FailsWhenServerCertRevoked drives a real TLS handshake against a listener
+// presenting a certificate that the CRL lists as revoked, and asserts the client rejects it.
+func TestTLSHandshake_FailsWhenServerCertRevoked(t *testing.T) {
+	ca := newTestCA(t)
+	serverTLSCert, leafCert := ca.issueLeaf(t, 42)
+	crlDER := ca.crl(t, leafCert.SerialNumber)
+
+	ts := &TrustStore{cfg: testConfig(t)}
+	require.NoError(t, os.WriteFile(ts.crlCacheFile(), crlDER, 0644))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverTLSCert},
+	})
+	require.NoError(t, err)
+	defer listener.Close()
+	go serveHandshake(listener)
+
+	clientConfig := &tls.Config{
+		RootCAs:               roots,
+		InsecureSkipVerify:    true, // verification happens entirely in VerifyPeerCertificate below
+		VerifyPeerCertificate: ts.VerifyPeerCertificate(),
+	}
+	_, err = tls.Dial("tcp", listener.Addr().String(), clientConfig)
+	assert.Error(t, err, "handshake must fail against a server cert on the CRL")
+}
+
+func TestTLSHandshake_SucceedsWhenServerCertNotRevoked(t *testing.T) {
+	ca := newTestCA(t)
+	serverTLSCert, _ := ca.issueLeaf(t, 42)
+	crlDER := ca.crl(t, big.NewInt(999)) // revokes an unrelated serial
+
+	ts := &TrustStore{cfg: testConfig(t)}
+	require.NoError(t, os.WriteFile(ts.crlCacheFile(), crlDER, 0644))
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverTLSCert},
+	})
+	require.NoError(t, err)
+	defer listener.Close()
+	go serveHandshake(listener)
+
+	clientConfig := &tls.Config{
+		RootCAs:               roots,
+		ServerName:            "127.0.0.1",
+		VerifyPeerCertificate: ts.VerifyPeerCertificate(),
+	}
+	conn, err := tls.Dial("tcp", listener.Addr().String(), clientConfig)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestShouldRefreshCA_MissingFileNeedsRefresh(t *testing.T) {
+	assert.True(t, shouldRefreshCA(filepath.Join(t.TempDir(), "does-not-exist.pem")))
+}
+
+func TestShouldRefreshCA_ForcedByEnv(t *testing.T) {
+	ca := newTestCA(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, ca.pem, 0644))
+
+	t.Setenv("EX_GOCD_SERVER_CA_REFRESH", "true")
+	assert.True(t, shouldRefreshCA(caFile))
+}
+
+func TestShouldRefreshCA_FreshCertDoesNotNeedRefresh(t *testing.T) {
+	// newTestCA issues a cert valid for only 24h - inside shouldRefreshCA's 30-day expiry
+	// window - so a "fresh" cert needs its own long-lived template here instead.
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(180 * 24 * time.Hour), // well outside the 30-day window
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+
+	assert.False(t, shouldRefreshCA(caFile))
+}
+
+func TestShouldRefreshCA_NearExpiryNeedsRefresh(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 24 * time.Hour), // within the 30-day window
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+
+	assert.True(t, shouldRefreshCA(caFile))
+}
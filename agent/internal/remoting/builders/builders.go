@@ -0,0 +1,78 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Builder decodes a GoCD BuildAssignment's "builders" array - Java Builder subtypes
+// (CommandBuilder, FetchArtifactBuilder, ...) discriminated by a "type" field - into typed Go
+// structs, and translates each into a protocol.BuildCommand node the executor can run.
+
+package builders
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Context carries assignment-wide values a Builder's ToBuildCommand needs beyond its own JSON
+// fields.
+type Context struct {
+	// WorkingDir is the job's working directory (BuildAssignment.BuildWorkingDirectory).
+	WorkingDir string
+	// ArtifactBaseURL is the server's remoting file-serving root (e.g.
+	// "https://gocd.example.com/go/remoting/files"), used by FetchArtifactBuilder to resolve an
+	// upstream job's published artifact into a downloadable URL.
+	ArtifactBaseURL string
+}
+
+// Builder translates one Java Builder subtype into a protocol.BuildCommand node.
+type Builder interface {
+	// Type is the JSON "type" discriminator this Builder was decoded from.
+	Type() string
+	// ToBuildCommand translates this builder into a BuildCommand, or (nil, nil) to contribute
+	// nothing to the build (see NullBuilder, PluggableFetchArtifactBuilder).
+	ToBuildCommand(ctx Context) (*protocol.BuildCommand, error)
+}
+
+// FilePath matches Java File serialization: {"path": "..."}.
+type FilePath struct {
+	Path string `json:"path"`
+}
+
+// typeEnvelope reads just the "type" discriminator, so Decode knows which concrete struct to
+// unmarshal the rest of the JSON into.
+type typeEnvelope struct {
+	Type string `json:"type"`
+}
+
+// constructors is keyed by the JSON "type" discriminator; each builder file registers its own
+// types via register in an init().
+var constructors = map[string]func() Builder{}
+
+// register adds a Builder constructor to the registry, keyed by its JSON "type" discriminator.
+func register(builderType string, newBuilder func() Builder) {
+	constructors[builderType] = newBuilder
+}
+
+// Decode unmarshals a BuildAssignment's raw "builders" array into typed Builders, routing any
+// discriminator the registry doesn't recognize to a NullBuilder instead of failing the whole
+// build over one unsupported step.
+func Decode(raw []json.RawMessage) ([]Builder, error) {
+	out := make([]Builder, 0, len(raw))
+	for _, r := range raw {
+		var env typeEnvelope
+		if err := json.Unmarshal(r, &env); err != nil {
+			return nil, fmt.Errorf("builders: decoding type discriminator: %w", err)
+		}
+		newBuilder, ok := constructors[env.Type]
+		if !ok {
+			out = append(out, &NullBuilder{BuilderType: env.Type})
+			continue
+		}
+		b := newBuilder()
+		if err := json.Unmarshal(r, b); err != nil {
+			return nil, fmt.Errorf("builders: decoding %s: %w", env.Type, err)
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package builders
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeOne(t *testing.T, raw string) Builder {
+	t.Helper()
+	bs, err := Decode([]json.RawMessage{json.RawMessage(raw)})
+	require.NoError(t, err)
+	require.Len(t, bs, 1)
+	return bs[0]
+}
+
+func TestDecode_CommandBuilderWithArgList(t *testing.T) {
+	b := decodeOne(t, `{"type":"CommandBuilderWithArgList","command":"echo","args":["hello"],"workingDir":{"path":"sub"}}`)
+	assert.Equal(t, "CommandBuilderWithArgList", b.Type())
+	cmd, err := b.ToBuildCommand(Context{WorkingDir: "/wd"})
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, protocol.CommandExec, cmd.Name)
+	assert.Equal(t, "echo", cmd.Command)
+	assert.Equal(t, []string{"hello"}, cmd.Args)
+	assert.Equal(t, "sub", cmd.WorkingDir)
+}
+
+func TestDecode_CommandBuilderStringArgs(t *testing.T) {
+	b := decodeOne(t, `{"type":"CommandBuilder","command":"git","args":"clone https://example.com repo"}`)
+	cmd, err := b.ToBuildCommand(Context{})
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, []string{"clone https://example.com repo"}, cmd.Args)
+}
+
+func TestDecode_FetchArtifactBuilder(t *testing.T) {
+	b := decodeOne(t, `{"type":"FetchArtifactBuilder","pipeline":"up","stage":"build","job":"unit","srcfile":"out.jar","dest":"libs","checksum":"sha256:abc"}`)
+	cmd, err := b.ToBuildCommand(Context{ArtifactBaseURL: "https://gocd.example.com/go/remoting/files"})
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, protocol.CommandFetch, cmd.Name)
+	assert.Equal(t, "https://gocd.example.com/go/remoting/files/up/build/unit/out.jar", cmd.URL)
+	assert.Equal(t, "libs", cmd.Dest)
+	assert.Equal(t, "sha256:abc", cmd.Checksum)
+}
+
+func TestDecode_FetchArtifactBuilder_MissingSrcFile(t *testing.T) {
+	b := decodeOne(t, `{"type":"FetchArtifactBuilder","pipeline":"up"}`)
+	_, err := b.ToBuildCommand(Context{})
+	assert.Error(t, err)
+}
+
+func TestDecode_DownloadFileBuilder(t *testing.T) {
+	b := decodeOne(t, `{"type":"DownloadFileBuilder","url":"https://example.com/file.tgz","dest":"deps/file.tgz"}`)
+	cmd, err := b.ToBuildCommand(Context{})
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, protocol.CommandFetch, cmd.Name)
+	assert.Equal(t, "https://example.com/file.tgz", cmd.URL)
+	assert.Equal(t, "deps/file.tgz", cmd.Dest)
+}
+
+func TestDecode_PluggableFetchArtifactBuilder_NoOp(t *testing.T) {
+	b := decodeOne(t, `{"type":"PluggableFetchArtifactBuilder","artifactId":"s3-artifact","configuration":{"bucket":"x"}}`)
+	cmd, err := b.ToBuildCommand(Context{})
+	require.NoError(t, err)
+	assert.Nil(t, cmd)
+}
+
+func TestDecode_PluggableTaskBuilder(t *testing.T) {
+	b := decodeOne(t, `{"type":"PluggableTaskBuilder","pluginConfiguration":{"id":"slack-notify","version":"1"},"configuration":{"channel":{"value":"#builds"}}}`)
+	cmd, err := b.ToBuildCommand(Context{})
+	require.NoError(t, err)
+	require.NotNil(t, cmd)
+	assert.Equal(t, protocol.CommandPluginExec, cmd.Name)
+	assert.Equal(t, "slack-notify", cmd.Attributes["pluginId"])
+	assert.Equal(t, map[string]string{"channel": "#builds"}, cmd.Attributes["config"])
+}
+
+func TestDecode_PluggableTaskBuilder_MissingPluginID(t *testing.T) {
+	b := decodeOne(t, `{"type":"PluggableTaskBuilder","pluginConfiguration":{}}`)
+	_, err := b.ToBuildCommand(Context{})
+	assert.Error(t, err)
+}
+
+func TestDecode_UnknownType_BecomesNullBuilder(t *testing.T) {
+	b := decodeOne(t, `{"type":"SomeFutureBuilder","whatever":1}`)
+	assert.Equal(t, "SomeFutureBuilder", b.Type())
+	cmd, err := b.ToBuildCommand(Context{})
+	require.NoError(t, err)
+	assert.Nil(t, cmd)
+}
+
+func TestDecode_NullBuilder(t *testing.T) {
+	b := decodeOne(t, `{"type":"NullBuilder"}`)
+	cmd, err := b.ToBuildCommand(Context{})
+	require.NoError(t, err)
+	assert.Nil(t, cmd)
+}
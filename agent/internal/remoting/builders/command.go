@@ -0,0 +1,63 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package builders
+
+import (
+	"encoding/json"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func init() {
+	register("CommandBuilder", func() Builder { return &CommandBuilder{} })
+	register("CommandBuilderWithArgList", func() Builder { return &CommandBuilder{} })
+}
+
+// ArgsField unmarshals a Java Builder's "args", which is either a JSON array of strings
+// (CommandBuilderWithArgList) or a single pre-split string (CommandBuilder) - the latter is kept
+// as one element rather than split, matching the agent's existing exec semantics.
+type ArgsField []string
+
+func (a *ArgsField) UnmarshalJSON(data []byte) error {
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err == nil {
+		*a = multi
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(data, &single); err != nil {
+		return err
+	}
+	if single != "" {
+		*a = []string{single}
+	}
+	return nil
+}
+
+// CommandBuilder translates a Java CommandBuilder/CommandBuilderWithArgList into an "exec"
+// BuildCommand.
+type CommandBuilder struct {
+	BuilderType string    `json:"type"`
+	Command     string    `json:"command"`
+	Args        ArgsField `json:"args"`
+	WorkingDir  *FilePath `json:"workingDir"`
+}
+
+func (b *CommandBuilder) Type() string { return b.BuilderType }
+
+func (b *CommandBuilder) ToBuildCommand(ctx Context) (*protocol.BuildCommand, error) {
+	if b.Command == "" {
+		return nil, nil
+	}
+	wd := ""
+	if b.WorkingDir != nil {
+		wd = b.WorkingDir.Path
+	}
+	return &protocol.BuildCommand{
+		Name:       protocol.CommandExec,
+		Command:    b.Command,
+		Args:       []string(b.Args),
+		WorkingDir: wd,
+	}, nil
+}
@@ -0,0 +1,92 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// FetchArtifactBuilder and DownloadFileBuilder translate a BuildAssignment's artifact-fetching
+// steps into protocol.CommandFetch nodes, run by executor.Fetch.
+
+package builders
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func init() {
+	register("FetchArtifactBuilder", func() Builder { return &FetchArtifactBuilder{} })
+	register("PluggableFetchArtifactBuilder", func() Builder { return &PluggableFetchArtifactBuilder{} })
+	register("DownloadFileBuilder", func() Builder { return &DownloadFileBuilder{} })
+}
+
+// FetchArtifactBuilder fetches a file published by a job's artifact store - usually an earlier
+// stage of the same pipeline, but Pipeline/Stage/Job may name any upstream job.
+type FetchArtifactBuilder struct {
+	BuilderType string `json:"type"`
+	Pipeline    string `json:"pipeline"`
+	Stage       string `json:"stage"`
+	Job         string `json:"job"`
+	SrcFile     string `json:"srcfile"`
+	Dest        string `json:"dest"`
+	Checksum    string `json:"checksum"`
+}
+
+func (b *FetchArtifactBuilder) Type() string { return b.BuilderType }
+
+// ToBuildCommand resolves the artifact's URL against ctx.ArtifactBaseURL - the server's
+// remoting/files root, the sibling of buildConsoleURL's console-log path.
+func (b *FetchArtifactBuilder) ToBuildCommand(ctx Context) (*protocol.BuildCommand, error) {
+	if b.SrcFile == "" {
+		return nil, fmt.Errorf("%s: srcfile is required", b.BuilderType)
+	}
+	url := strings.TrimSuffix(ctx.ArtifactBaseURL, "/") + "/" + path.Join(b.Pipeline, b.Stage, b.Job, b.SrcFile)
+	return &protocol.BuildCommand{
+		Name:     protocol.CommandFetch,
+		URL:      url,
+		Dest:     b.Dest,
+		Checksum: b.Checksum,
+	}, nil
+}
+
+// PluggableFetchArtifactBuilder fetches an artifact through a GoCD artifact plugin (e.g. S3,
+// Artifactory) instead of the server's own file store. Resolving Configuration against a specific
+// plugin's API requires the executor plugin subprocess protocol (see
+// agent/internal/executor/plugin), not a plain file download, so it is not implemented: like
+// NullBuilder, it logs and contributes no BuildCommand, so the artifact is not fetched and the
+// rest of the build runs without it.
+type PluggableFetchArtifactBuilder struct {
+	BuilderType   string                 `json:"type"`
+	ArtifactID    string                 `json:"artifactId"`
+	Configuration map[string]interface{} `json:"configuration"`
+}
+
+func (b *PluggableFetchArtifactBuilder) Type() string { return b.BuilderType }
+
+func (b *PluggableFetchArtifactBuilder) ToBuildCommand(ctx Context) (*protocol.BuildCommand, error) {
+	log.Printf("builders: PluggableFetchArtifactBuilder %q not supported yet; skipping", b.ArtifactID)
+	return nil, nil
+}
+
+// DownloadFileBuilder downloads an arbitrary URL (e.g. a dependency published outside GoCD) to
+// Dest, optionally verifying Checksum.
+type DownloadFileBuilder struct {
+	BuilderType string `json:"type"`
+	URL         string `json:"url"`
+	Dest        string `json:"dest"`
+	Checksum    string `json:"checksum"`
+}
+
+func (b *DownloadFileBuilder) Type() string { return b.BuilderType }
+
+func (b *DownloadFileBuilder) ToBuildCommand(ctx Context) (*protocol.BuildCommand, error) {
+	if b.URL == "" {
+		return nil, fmt.Errorf("%s: url is required", b.BuilderType)
+	}
+	return &protocol.BuildCommand{
+		Name:     protocol.CommandFetch,
+		URL:      b.URL,
+		Dest:     b.Dest,
+		Checksum: b.Checksum,
+	}, nil
+}
@@ -0,0 +1,29 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package builders
+
+import (
+	"log"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func init() {
+	register("NullBuilder", func() Builder { return &NullBuilder{} })
+}
+
+// NullBuilder is GoCD's own no-op builder (used for steps the server has already resolved to
+// nothing to run), and also what Decode substitutes for any "type" discriminator it doesn't
+// recognize - it logs and contributes no BuildCommand rather than failing the whole build over
+// one unsupported step.
+type NullBuilder struct {
+	BuilderType string `json:"type"`
+}
+
+func (b *NullBuilder) Type() string { return b.BuilderType }
+
+func (b *NullBuilder) ToBuildCommand(ctx Context) (*protocol.BuildCommand, error) {
+	log.Printf("builders: skipping unsupported builder type %q", b.BuilderType)
+	return nil, nil
+}
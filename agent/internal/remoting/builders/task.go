@@ -0,0 +1,57 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// PluggableTaskBuilder translates a BuildAssignment's Pluggable Task step into a
+// protocol.CommandPluginExec node, run by executor.PluginExec through agent/pkg/plugins.
+
+package builders
+
+import (
+	"fmt"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func init() {
+	register("PluggableTaskBuilder", func() Builder { return &PluggableTaskBuilder{} })
+}
+
+// PluginConfiguration names the task plugin (and, optionally, a specific version) a
+// PluggableTaskBuilder step runs.
+type PluginConfiguration struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// PluggableTaskConfigValue matches Java's wrapped configuration-property shape
+// ({"key": {"value": "..."}}) rather than a flat string, since a plugin's property may carry a
+// server-side "secure" flag this agent doesn't need to act on.
+type PluggableTaskConfigValue struct {
+	Value string `json:"value"`
+}
+
+// PluggableTaskBuilder runs one step of a GoCD Pluggable Task plugin (e.g. a notification task,
+// a custom deploy step), identified by PluginConfiguration.ID and configured by Configuration.
+type PluggableTaskBuilder struct {
+	BuilderType         string                              `json:"type"`
+	PluginConfiguration PluginConfiguration                 `json:"pluginConfiguration"`
+	Configuration       map[string]PluggableTaskConfigValue `json:"configuration"`
+}
+
+func (b *PluggableTaskBuilder) Type() string { return b.BuilderType }
+
+func (b *PluggableTaskBuilder) ToBuildCommand(ctx Context) (*protocol.BuildCommand, error) {
+	if b.PluginConfiguration.ID == "" {
+		return nil, fmt.Errorf("%s: pluginConfiguration.id is required", b.BuilderType)
+	}
+	config := make(map[string]string, len(b.Configuration))
+	for key, value := range b.Configuration {
+		config[key] = value.Value
+	}
+	return &protocol.BuildCommand{
+		Name: protocol.CommandPluginExec,
+		Attributes: map[string]interface{}{
+			"pluginId": b.PluginConfiguration.ID,
+			"config":   config,
+		},
+	}, nil
+}
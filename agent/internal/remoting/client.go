@@ -7,14 +7,20 @@ package remoting
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/internal/remoting/builders"
 	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+	"github.com/fsnotify/fsnotify"
 )
 
 const (
@@ -24,58 +30,175 @@ const (
 	contentTypeJSON   = "application/json"
 )
 
+// ErrUnauthorized is returned by post when the server rejects the (possibly just-reloaded) token
+// with a 401, so the caller knows to fall back to full re-registration rather than retrying.
+var ErrUnauthorized = errors.New("remoting: server rejected agent token")
+
 // Client calls the GoCD remoting API (get_work, get_cookie, etc.).
 type Client struct {
 	baseURL    string
 	uuid       string
-	token      string
 	httpClient *http.Client
+
+	tokenFile string
+	tokenMu   sync.RWMutex
+	token     string
+	watcher   *fsnotify.Watcher
 }
 
-// NewClient builds a remoting client. Token is read from cfg.AgentTokenFile().
+// NewClient builds a remoting client. Token is read from cfg.AgentTokenFile() and kept fresh by
+// watching that file for writes, so a token rewritten by the Registrar (see
+// internal/registration.Registrar.requestToken) is picked up without an agent restart.
 func NewClient(cfg *config.Config, httpClient *http.Client) (*Client, error) {
-	token, err := os.ReadFile(cfg.AgentTokenFile())
+	tokenFile := cfg.AgentTokenFile()
+	token, err := readToken(tokenFile)
 	if err != nil {
 		return nil, fmt.Errorf("read agent token: %w", err)
 	}
-	return &Client{
+	c := &Client{
 		baseURL:    cfg.RemotingBaseURL(),
 		uuid:       cfg.UUID,
-		token:      string(bytes.TrimSpace(token)),
 		httpClient: httpClient,
-	}, nil
+		tokenFile:  tokenFile,
+		token:      token,
+	}
+	if err := c.watchToken(); err != nil {
+		log.Printf("remoting: watch token file %s: %v (token rotation will require a restart)", tokenFile, err)
+	}
+	return c, nil
+}
+
+// readToken reads and trims the agent token file.
+func readToken(path string) (string, error) {
+	token, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(token)), nil
+}
+
+// watchToken starts a goroutine reloading c.token whenever tokenFile is written - editors and
+// os.WriteFile (used by Registrar.requestToken) both emit Write, and some replace-by-rename
+// instead, so Create is watched too. The token file's directory is watched rather than the file
+// itself, since a rename replaces the watched inode.
+func (c *Client) watchToken() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(c.tokenFile)); err != nil {
+		watcher.Close()
+		return err
+	}
+	c.watcher = watcher
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(c.tokenFile) || event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+				token, err := readToken(c.tokenFile)
+				if err != nil {
+					log.Printf("remoting: reload token: %v", err)
+					continue
+				}
+				c.tokenMu.Lock()
+				c.token = token
+				c.tokenMu.Unlock()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("remoting: token watcher: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops the token file watcher. Safe to call even if watching failed to start.
+func (c *Client) Close() error {
+	if c.watcher == nil {
+		return nil
+	}
+	return c.watcher.Close()
+}
+
+func (c *Client) currentToken() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.token
 }
 
-// post sends a POST to the remoting API with auth headers and JSON body.
+// reloadToken re-reads tokenFile from disk, for the retry-after-401 path below - a 401 can mean
+// the token was rotated on disk after this watcher's last event was still in flight.
+func (c *Client) reloadToken() string {
+	token, err := readToken(c.tokenFile)
+	if err != nil {
+		log.Printf("remoting: reload token after 401: %v", err)
+		return c.currentToken()
+	}
+	c.tokenMu.Lock()
+	c.token = token
+	c.tokenMu.Unlock()
+	return token
+}
+
+// post sends a POST to the remoting API with auth headers and JSON body. On a 401 it reloads the
+// token from disk and retries once, since a renewed token may not have reached the watcher yet;
+// a second 401 is surfaced as ErrUnauthorized so the caller can trigger re-registration.
 func (c *Client) post(action string, reqBody interface{}) ([]byte, error) {
+	out, status, err := c.doPost(action, reqBody, c.currentToken())
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusUnauthorized {
+		out, status, err = c.doPost(action, reqBody, c.reloadToken())
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusUnauthorized {
+			return nil, fmt.Errorf("remoting %s: %w", action, ErrUnauthorized)
+		}
+	}
+	if status >= 400 {
+		return nil, fmt.Errorf("remoting %s: status %d, body: %s", action, status, string(out))
+	}
+	return out, nil
+}
+
+// doPost performs a single POST attempt with the given token, returning the raw status code so
+// post can decide whether to retry.
+func (c *Client) doPost(action string, reqBody interface{}, token string) ([]byte, int, error) {
 	url := c.baseURL + action
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	req.Header.Set(headerAgentGUID, c.uuid)
-	req.Header.Set(headerAuth, c.token)
+	req.Header.Set(headerAuth, token)
 	req.Header.Set("Accept", acceptGoCDJSON)
 	req.Header.Set("Content-Type", contentTypeJSON)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer resp.Body.Close()
 
 	out, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("remoting %s: status %d, body: %s", action, resp.StatusCode, string(out))
+		return nil, 0, err
 	}
-	return out, nil
+	return out, resp.StatusCode, nil
 }
 
 // GetWorkRequest is the request body for get_work (matches GoCD GetWorkRequest).
@@ -126,10 +249,13 @@ type Work struct {
 }
 
 // BuildAssignment matches Java BuildAssignment: buildWorkingDirectory (File), builders, jobIdentifier.
+// Builders is kept as raw JSON (rather than map[string]interface{}) so builders.Decode can
+// unmarshal each element into its typed Go struct once its "type" discriminator is known.
 type BuildAssignment struct {
-	BuildWorkingDirectory *FilePath       `json:"buildWorkingDirectory,omitempty"`
-	Builders              []interface{}  `json:"builders,omitempty"`
-	JobIdentifier         *JobIdentifier `json:"jobIdentifier,omitempty"`
+	BuildWorkingDirectory *FilePath               `json:"buildWorkingDirectory,omitempty"`
+	Builders              []json.RawMessage       `json:"builders,omitempty"`
+	JobIdentifier         *JobIdentifier          `json:"jobIdentifier,omitempty"`
+	ArtifactPlans         []protocol.ArtifactPlan `json:"artifactPlans,omitempty"`
 }
 
 // FilePath matches Java File serialization: {"path": "..."}.
@@ -184,15 +310,28 @@ func (w *Work) ToBuild(serverBaseURL string) *protocol.Build {
 	}
 	// Console URL: GoCD uses /remoting/files/<pipeline>/<label>/<stage>/<counter>/<job>/cruise-output/console.log
 	consoleURL := buildConsoleURL(serverBaseURL, a.JobIdentifier)
-	buildCmd := remotingAssignmentToBuildCommand(a)
+	buildCmd, err := remotingAssignmentToBuildCommand(a, artifactBaseURL(serverBaseURL))
+	if err != nil {
+		log.Printf("remoting: building BuildCommand tree: %v", err)
+	}
 	return &protocol.Build{
 		BuildId:       jobID,
 		BuildLocator:  jobID,
 		ConsoleUrl:    consoleURL,
 		BuildCommand:  buildCmd,
+		ArtifactPlans: a.ArtifactPlans,
 	}
 }
 
+// artifactBaseURL returns the GoCD remoting file-serving root (server base + "remoting/files"),
+// used to resolve a FetchArtifactBuilder's upstream job/srcfile into a downloadable URL.
+func artifactBaseURL(serverBaseURL string) string {
+	if serverBaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(serverBaseURL, "/") + "/remoting/files"
+}
+
 // buildConsoleURL returns the GoCD remoting console log URL for a job (server base + standard path).
 func buildConsoleURL(serverBaseURL string, jobID *JobIdentifier) string {
 	if serverBaseURL == "" || jobID == nil {
@@ -208,73 +347,41 @@ func buildConsoleURL(serverBaseURL string, jobID *JobIdentifier) string {
 	return base + "/" + path
 }
 
-// remotingAssignmentToBuildCommand converts GoCD BuildAssignment to our BuildCommand tree.
-// Java BuildAssignment has buildWorkingDirectory (File), builders (List<Builder>). Builder subtypes: CommandBuilder, CommandBuilderWithArgList, etc.
-func remotingAssignmentToBuildCommand(a *BuildAssignment) *protocol.BuildCommand {
+// remotingAssignmentToBuildCommand converts a GoCD BuildAssignment to our BuildCommand tree by
+// decoding its builders (see remoting/builders) and translating each into a BuildCommand node.
+func remotingAssignmentToBuildCommand(a *BuildAssignment, artifactBaseURL string) (*protocol.BuildCommand, error) {
 	if a == nil || len(a.Builders) == 0 {
-		return nil
+		return nil, nil
 	}
 	workingDir := ""
 	if a.BuildWorkingDirectory != nil {
 		workingDir = a.BuildWorkingDirectory.Path
 	}
+
+	decoded, err := builders.Decode(a.Builders)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := builders.Context{WorkingDir: workingDir, ArtifactBaseURL: artifactBaseURL}
 	var subCommands []*protocol.BuildCommand
-	for _, b := range a.Builders {
-		cmd := mapBuilderToCommand(b)
+	for _, b := range decoded {
+		cmd, err := b.ToBuildCommand(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("builder %s: %w", b.Type(), err)
+		}
 		if cmd != nil {
 			subCommands = append(subCommands, cmd)
 		}
 	}
 	if len(subCommands) == 0 {
-		return nil
+		return nil, nil
 	}
 	return &protocol.BuildCommand{
-		Name:        "compose",
+		Name:        protocol.CommandCompose,
 		SubCommands: subCommands,
 		WorkingDir:  workingDir,
-	}
-}
-
-// mapBuilderToCommand maps Java Builder JSON to our BuildCommand.
-// Subtypes: CommandBuilder (args string), CommandBuilderWithArgList (args []string). Both have type, command, workingDir (File with path).
-func mapBuilderToCommand(b interface{}) *protocol.BuildCommand {
-	m, ok := b.(map[string]interface{})
-	if !ok {
-		return nil
-	}
-	// Skip non-command builders (NullBuilder, FetchArtifactBuilder, etc.)
-	builderType, _ := m["type"].(string)
-	cmd, _ := m["command"].(string)
-	if cmd == "" {
-		return nil
-	}
-	var args []string
-	switch a := m["args"].(type) {
-	case []interface{}:
-		for _, v := range a {
-			if s, ok := v.(string); ok {
-				args = append(args, s)
-			}
-		}
-	case string:
-		if a != "" {
-			args = append(args, a)
-		}
-	}
-	wd := ""
-	if w, ok := m["workingDir"].(map[string]interface{}); ok {
-		wd, _ = w["path"].(string)
-	}
-	// CommandBuilder and CommandBuilderWithArgList both become exec
-	if builderType == "CommandBuilder" || builderType == "CommandBuilderWithArgList" || cmd != "" {
-		return &protocol.BuildCommand{
-			Name:       "exec",
-			Command:   cmd,
-			Args:      args,
-			WorkingDir: wd,
-		}
-	}
-	return nil
+	}, nil
 }
 
 // ReportCurrentStatusRequest is the body for report_current_status.
@@ -289,6 +396,9 @@ type ReportCompleteStatusRequest struct {
 	AgentRuntimeInfo *protocol.AgentRuntimeInfo `json:"agentRuntimeInfo"`
 	JobIdentifier    *JobIdentifier             `json:"jobIdentifier"`
 	JobResult        string                     `json:"jobResult"`
+	// TestResults is set on report_completed when the build declared "unit" ArtifactPlans - see
+	// internal/testresults.Collect.
+	TestResults *protocol.TestResultsSummary `json:"testResults,omitempty"`
 }
 
 // ReportCurrentStatus sends Building/Completing state to the server.
@@ -311,12 +421,14 @@ func (c *Client) ReportCompleting(runtimeInfo *protocol.AgentRuntimeInfo, jobID
 	return err
 }
 
-// ReportCompleted sends the job has completed.
-func (c *Client) ReportCompleted(runtimeInfo *protocol.AgentRuntimeInfo, jobID *JobIdentifier, result string) error {
+// ReportCompleted sends the job has completed, with testResults (nil if the build declared no
+// "unit" ArtifactPlans) summarizing any parsed test reports - see internal/testresults.Collect.
+func (c *Client) ReportCompleted(runtimeInfo *protocol.AgentRuntimeInfo, jobID *JobIdentifier, result string, testResults *protocol.TestResultsSummary) error {
 	_, err := c.post("/report_completed", &ReportCompleteStatusRequest{
 		AgentRuntimeInfo: runtimeInfo,
 		JobIdentifier:    jobID,
 		JobResult:        result,
+		TestResults:      testResults,
 	})
 	return err
 }
@@ -5,12 +5,15 @@ package remoting
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/d-led/ex_gocd/agent/internal/config"
 	"github.com/d-led/ex_gocd/agent/pkg/protocol"
@@ -181,3 +184,83 @@ func TestPing_CANCEL_viaServer(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "CANCEL", instruction)
 }
+
+// newTestClientWithToken builds a Client against server, writing initialToken to cfg's token
+// file first.
+func newTestClientWithToken(t *testing.T, server *httptest.Server, initialToken string) (*Client, string) {
+	t.Helper()
+	dir := t.TempDir()
+	configDir := filepath.Join(dir, "config")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	tokenPath := filepath.Join(configDir, "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte(initialToken), 0644))
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	cfg := &config.Config{ServerURL: serverURL, WorkDir: dir, ConfigDir: configDir, UUID: "test-uuid"}
+	client, err := NewClient(cfg, server.Client())
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client, tokenPath
+}
+
+// TestPost_RetriesOnceAfterRotatedToken401s covers the stale-token case: the server rejects the
+// first attempt's token, the token file is rotated on disk (simulating a re-registration) before
+// the retry, and the retry with the reloaded token succeeds.
+func TestPost_RetriesOnceAfterRotatedToken401s(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		if n == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, "rotated-token", r.Header.Get(headerAuth))
+		_, _ = w.Write([]byte(`"NONE"`))
+	}))
+	defer server.Close()
+
+	client, tokenPath := newTestClientWithToken(t, server, "stale-token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("rotated-token"), 0644))
+
+	instruction, err := client.Ping(&protocol.AgentRuntimeInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, "NONE", instruction)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+// TestPost_SurfacesErrUnauthorizedWhenTokenStillRejected covers a fully revoked token: both the
+// initial attempt and the retry-with-reloaded-token 401, so ErrUnauthorized is surfaced for the
+// caller to trigger re-registration.
+func TestPost_SurfacesErrUnauthorizedWhenTokenStillRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClientWithToken(t, server, "revoked-token")
+
+	_, err := client.Ping(&protocol.AgentRuntimeInfo{})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+}
+
+// TestClient_ReloadsTokenOnFileChange ensures the fsnotify watcher picks up a rotated token
+// without any request needing to 401 first.
+func TestClient_ReloadsTokenOnFileChange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`"` + r.Header.Get(headerAuth) + `"`))
+	}))
+	defer server.Close()
+
+	client, tokenPath := newTestClientWithToken(t, server, "first-token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("second-token"), 0644))
+
+	require.Eventually(t, func() bool {
+		return client.currentToken() == "second-token"
+	}, time.Second, 10*time.Millisecond, "watcher should have reloaded the rotated token")
+
+	instruction, err := client.Ping(&protocol.AgentRuntimeInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, "second-token", instruction)
+}
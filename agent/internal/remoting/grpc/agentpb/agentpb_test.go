@@ -0,0 +1,63 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package agentpb
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// echoAgentStreamServer replies with the same AgentMessage it receives, once, so the test can
+// assert what came out the other end of a real gRPC round trip.
+type echoAgentStreamServer struct{}
+
+func (echoAgentStreamServer) Stream(stream AgentStream_StreamServer) error {
+	m, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	return stream.Send(m)
+}
+
+// TestAgentMessage_RoundTripsOverRealGRPC drives an AgentMessage through an actual grpc.Server/
+// ClientConn (via bufconn, no TCP needed) instead of just converting structs in memory. This is
+// the wire-marshaling step that silently failed when agentpb's types were hand-written structs
+// without ProtoReflect/Reset/String - see grpc/doc.go's //go:generate directive.
+func TestAgentMessage_RoundTripsOverRealGRPC(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	defer lis.Close()
+
+	srv := grpc.NewServer()
+	RegisterAgentStreamServer(srv, echoAgentStreamServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	ctx := context.Background()
+	cc, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer cc.Close()
+
+	stream, err := NewAgentStreamClient(cc).Stream(ctx)
+	require.NoError(t, err)
+
+	want := &AgentMessage{Action: "ping", Data: []byte(`{"foo":"bar"}`), AckId: "ack-1", MessageType: "msg"}
+	require.NoError(t, stream.Send(want))
+
+	got, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, want.Action, got.Action)
+	assert.Equal(t, want.Data, got.Data)
+	assert.Equal(t, want.AckId, got.AckId)
+	assert.Equal(t, want.MessageType, got.MessageType)
+}
@@ -0,0 +1,138 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/internal/remoting/grpc/agentpb"
+	"github.com/d-led/ex_gocd/agent/internal/remoting/transport"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Connection implements transport.Transport over the AgentStream bidi-streaming RPC: the
+// AGENT_TRANSPORT=grpc path.
+var _ transport.Transport = (*Connection)(nil)
+
+// Connection wraps the AgentStream client stream and handles message routing.
+type Connection struct {
+	cc     *grpc.ClientConn
+	stream agentpb.AgentStream_StreamClient
+	cookie string
+
+	send    chan *protocol.Message
+	receive chan *protocol.Message
+	done    chan struct{}
+}
+
+// Connect dials the server's gRPC endpoint and opens the AgentStream. tlsConfig is the same
+// mTLS configuration the WebSocket path builds from the agent's certificate files
+// (config.AgentCertFile, AgentPrivateKeyFile, GoServerCAFile) - see agent.Agent.currentTLSConfig.
+func Connect(ctx context.Context, cfg *config.Config, tlsConfig *tls.Config) (*Connection, error) {
+	addr := cfg.GRPCAddress()
+
+	log.Printf("Connecting to gRPC transport: %s", addr)
+	cc, err := grpc.DialContext(ctx, addr,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithBlock(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+	}
+
+	stream, err := agentpb.NewAgentStreamClient(cc).Stream(ctx)
+	if err != nil {
+		cc.Close()
+		return nil, fmt.Errorf("failed to open AgentStream: %w", err)
+	}
+
+	log.Println("gRPC AgentStream established")
+	c := &Connection{
+		cc:      cc,
+		stream:  stream,
+		send:    make(chan *protocol.Message, 10),
+		receive: make(chan *protocol.Message, 10),
+		done:    make(chan struct{}),
+	}
+
+	go c.readPump()
+	go c.writePump()
+
+	return c, nil
+}
+
+// Send queues a message to be sent.
+func (c *Connection) Send(msg *protocol.Message) {
+	select {
+	case c.send <- msg:
+	case <-c.done:
+	}
+}
+
+// Receive returns the receive channel.
+func (c *Connection) Receive() <-chan *protocol.Message {
+	return c.receive
+}
+
+// SetCookie stores the session cookie from server.
+func (c *Connection) SetCookie(cookie string) {
+	c.cookie = cookie
+}
+
+// Close tears down the AgentStream and the underlying gRPC connection.
+func (c *Connection) Close() error {
+	close(c.done)
+	c.stream.CloseSend()
+	return c.cc.Close()
+}
+
+// readPump reads AgentMessages from the stream, converts them, and forwards ACKs just like
+// websocket.Connection.readPump.
+func (c *Connection) readPump() {
+	defer close(c.receive)
+
+	for {
+		m, err := c.stream.Recv()
+		if err != nil {
+			log.Printf("gRPC stream read error: %v", err)
+			return
+		}
+		msg := fromProto(m)
+
+		if msg.AckId != "" {
+			c.Send(protocol.AckMessage(msg.AckId))
+		}
+
+		select {
+		case c.receive <- msg:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// writePump writes queued messages to the AgentStream.
+func (c *Connection) writePump() {
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			if err := c.stream.Send(toProto(msg)); err != nil {
+				log.Printf("gRPC stream write error: %v", err)
+				return
+			}
+
+		case <-c.done:
+			return
+		}
+	}
+}
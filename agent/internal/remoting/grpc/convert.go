@@ -0,0 +1,42 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package grpc
+
+import (
+	"github.com/d-led/ex_gocd/agent/internal/remoting/grpc/agentpb"
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// toProto converts a protocol.Message into its AgentMessage wire form.
+func toProto(msg *protocol.Message) *agentpb.AgentMessage {
+	return &agentpb.AgentMessage{
+		Action:      msg.Action,
+		Data:        msg.Data,
+		AckId:       msg.AckId,
+		MessageType: msg.MessageType,
+	}
+}
+
+// fromProto converts a received AgentMessage back into the shared protocol.Message model, so
+// agent.Agent's message handling doesn't need to know which transport delivered it.
+func fromProto(m *agentpb.AgentMessage) *protocol.Message {
+	return &protocol.Message{
+		Action:      m.Action,
+		Data:        m.Data,
+		AckId:       m.AckId,
+		MessageType: m.MessageType,
+	}
+}
+
+// toProtoLogLine converts a protocol.LogLine into its LogLine wire form for LogStream.Append.
+func toProtoLogLine(line protocol.LogLine) *agentpb.LogLine {
+	return &agentpb.LogLine{
+		BuildId: line.BuildId,
+		Proc:    line.Proc,
+		Time:    line.Time,
+		Pos:     int32(line.Pos),
+		Out:     line.Out,
+		Msg:     line.Msg,
+	}
+}
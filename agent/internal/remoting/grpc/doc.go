@@ -0,0 +1,12 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Package grpc is the gRPC alternative to the WebSocket JSON transport (see
+// agent/internal/websocket), selected with AGENT_TRANSPORT=grpc. Its Connection speaks the
+// AgentStream service defined in proto/agent.proto; the LogStream and ArtifactStream services
+// declared there have generated clients in agentpb but are not called from this package. It
+// reuses the agent's existing mTLS certificates (config.AgentCertFile, AgentPrivateKeyFile, GoServerCAFile) via the
+// same *tls.Config callers already build for the WebSocket path.
+//
+//go:generate protoc --go_out=../../../.. --go-grpc_out=../../../.. ../../../../proto/agent.proto
+
+package grpc
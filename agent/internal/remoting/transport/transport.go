@@ -0,0 +1,27 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Transport abstracts the channel the agent uses to exchange protocol.Message with the server,
+// so agent.Agent can run over either websocket.Connection (the default) or grpc.Connection
+// (AGENT_TRANSPORT=grpc) without caring which.
+
+package transport
+
+import (
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Transport is the method set both websocket.Connection and grpc.Connection implement.
+type Transport interface {
+	// Send queues msg to be delivered to the server.
+	Send(msg *protocol.Message)
+
+	// Receive returns the channel of messages arriving from the server; it's closed when the
+	// connection goes down.
+	Receive() <-chan *protocol.Message
+
+	// SetCookie stores the session cookie the server assigned this agent.
+	SetCookie(cookie string)
+
+	// Close tears down the connection.
+	Close() error
+}
@@ -0,0 +1,141 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// EnvProvider resolves a SecretRef by reading an environment variable named by the locator
+// (ref.Value, with any "env:" scheme prefix stripped).
+type EnvProvider struct{}
+
+func (EnvProvider) Resolve(ref protocol.SecretRef) (string, error) {
+	name := stripScheme(ref.Value)
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s not set", name)
+	}
+	return value, nil
+}
+
+// FileProvider resolves a SecretRef by reading a file's contents (e.g. a Docker/Kubernetes
+// secret mounted into the agent's filesystem), trimming a single trailing newline.
+type FileProvider struct{}
+
+func (FileProvider) Resolve(ref protocol.SecretRef) (string, error) {
+	path := stripScheme(ref.Value)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// VaultProvider resolves a SecretRef against a HashiCorp Vault KV v2 mount, reading the token
+// from VAULT_TOKEN and the server address from VAULT_ADDR (or the Addr field, for tests).
+// The locator is "<mount>/<path>#<field>", e.g. "secret/ci/github#token".
+type VaultProvider struct {
+	Addr   string // defaults to os.Getenv("VAULT_ADDR")
+	Token  string // defaults to os.Getenv("VAULT_TOKEN")
+	Client *http.Client
+}
+
+func (v VaultProvider) Resolve(ref protocol.SecretRef) (string, error) {
+	addr := v.Addr
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if addr == "" {
+		return "", fmt.Errorf("VAULT_ADDR not set")
+	}
+	token := v.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		return "", fmt.Errorf("VAULT_TOKEN not set")
+	}
+
+	mountPath, field, err := parseVaultLocator(stripScheme(ref.Value))
+	if err != nil {
+		return "", err
+	}
+
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	url := strings.TrimSuffix(addr, "/") + "/v1/" + mountPath
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault %s: %s", url, resp.Status)
+	}
+
+	return extractVaultField(resp.Body, field)
+}
+
+// parseVaultLocator splits "<mount>/<path>#<field>" into the KV v2 read path and the field
+// name within the secret's data.
+func parseVaultLocator(locator string) (mountPath, field string, err error) {
+	i := strings.LastIndex(locator, "#")
+	if i < 0 {
+		return "", "", fmt.Errorf("vault locator %q missing #field", locator)
+	}
+	path, field := locator[:i], locator[i+1:]
+	if path == "" || field == "" {
+		return "", "", fmt.Errorf("vault locator %q missing mount/path or field", locator)
+	}
+	// KV v2 reads go through .../data/<path> rather than .../<path>.
+	if slash := strings.Index(path, "/"); slash >= 0 {
+		path = path[:slash] + "/data" + path[slash:]
+	}
+	return path, field, nil
+}
+
+func stripScheme(locator string) string {
+	_, rest := splitScheme(locator)
+	if rest == "" {
+		return locator
+	}
+	return rest
+}
+
+// vaultKVv2Response is the subset of a KV v2 read response this provider needs.
+type vaultKVv2Response struct {
+	Data struct {
+		Data map[string]string `json:"data"`
+	} `json:"data"`
+}
+
+// extractVaultField pulls field out of a KV v2 response body: {"data":{"data":{field:value}}}.
+func extractVaultField(body io.Reader, field string) (string, error) {
+	var parsed vaultKVv2Response
+	if err := json.NewDecoder(body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("decoding vault response: %w", err)
+	}
+	value, ok := parsed.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found in vault secret", field)
+	}
+	return value, nil
+}
@@ -0,0 +1,95 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package secrets
+
+import (
+	"bytes"
+	"io"
+)
+
+// mask replaces a matched secret in console output; intentionally fixed-width so the masked
+// length doesn't leak the secret's length.
+const mask = "******"
+
+// RedactingWriter wraps dest, replacing every occurrence of any of values with mask before the
+// bytes reach dest. It holds back a suffix buffer the length of the longest value so a secret
+// split across two Write calls is still caught - e.g. stdout arriving as two separate reads
+// from a pipe mid-secret.
+type RedactingWriter struct {
+	dest    io.Writer
+	values  [][]byte
+	maxLen  int
+	pending []byte
+}
+
+// NewRedactingWriter creates a RedactingWriter over dest. Empty values are ignored (matching
+// everything would make console output useless).
+func NewRedactingWriter(dest io.Writer, values []string) *RedactingWriter {
+	w := &RedactingWriter{dest: dest}
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		w.values = append(w.values, []byte(v))
+		if len(v) > w.maxLen {
+			w.maxLen = len(v)
+		}
+	}
+	return w
+}
+
+// Write implements io.Writer.
+func (w *RedactingWriter) Write(p []byte) (int, error) {
+	if len(w.values) == 0 {
+		return w.dest.Write(p)
+	}
+
+	w.pending = append(w.pending, p...)
+
+	// Redact the whole buffer first, so a complete secret is never cut in two, then keep the
+	// trailing maxLen-1 bytes back: a secret could start there and still need bytes from the
+	// next Write to complete.
+	holdBack := w.maxLen - 1
+	if holdBack < 0 {
+		holdBack = 0
+	}
+	if len(w.pending) <= holdBack {
+		return len(p), nil
+	}
+
+	redacted := w.redact(w.pending)
+	if len(redacted) <= holdBack {
+		w.pending = redacted
+		return len(p), nil
+	}
+	cut := len(redacted) - holdBack
+	w.pending = append([]byte(nil), redacted[cut:]...)
+
+	if _, err := w.dest.Write(redacted[:cut]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close flushes any held-back bytes (fully redacted, since no further data can complete a
+// split secret) and closes dest if it implements io.Closer.
+func (w *RedactingWriter) Close() error {
+	if len(w.pending) > 0 {
+		if _, err := w.dest.Write(w.redact(w.pending)); err != nil {
+			return err
+		}
+		w.pending = nil
+	}
+	if c, ok := w.dest.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (w *RedactingWriter) redact(b []byte) []byte {
+	for _, v := range w.values {
+		b = bytes.ReplaceAll(b, v, []byte(mask))
+	}
+	return b
+}
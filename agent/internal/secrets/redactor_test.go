@@ -0,0 +1,60 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package secrets
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRedactingWriter_MasksSecretWithinOneWrite(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewRedactingWriter(&buf, []string{"s3cr3t"})
+
+	if _, err := w.Write([]byte("token=s3cr3t done\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	w.Close()
+
+	if got := buf.String(); got != "token=****** done\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRedactingWriter_MasksSecretSplitAcrossWrites(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewRedactingWriter(&buf, []string{"s3cr3t"})
+
+	w.Write([]byte("token=s3c"))
+	w.Write([]byte("r3t done\n"))
+	w.Close()
+
+	if got := buf.String(); got != "token=****** done\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRedactingWriter_NoSecretsPassesThrough(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewRedactingWriter(&buf, nil)
+
+	w.Write([]byte("hello\n"))
+	w.Close()
+
+	if got := buf.String(); got != "hello\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRedactingWriter_EmptyValuesIgnored(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewRedactingWriter(&buf, []string{"", "abc"})
+
+	w.Write([]byte("xabcx\n"))
+	w.Close()
+
+	if got := buf.String(); got != "x******x\n" {
+		t.Errorf("got %q", got)
+	}
+}
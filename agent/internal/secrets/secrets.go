@@ -0,0 +1,117 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Secret resolution and redaction for BuildCommand execution: the server ships
+// protocol.SecretRef pointers (provider + locator), never values, and the agent fetches the
+// actual value lazily at build start via a Provider - see Resolve and RedactingWriter.
+
+package secrets
+
+import (
+	"fmt"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Secret is a resolved secret value, ready to be injected into a command's environment or
+// masked in console output. The value never gets logged - only Key and Scope are safe to log.
+type Secret struct {
+	Key   string
+	Value string
+	Scope string
+}
+
+// Provider resolves a protocol.SecretRef's locator (SecretRef.Value) into the actual secret
+// value. Implementations: EnvProvider, FileProvider, VaultProvider.
+type Provider interface {
+	Resolve(ref protocol.SecretRef) (string, error)
+}
+
+// Resolve fetches a value for each ref via providers, keyed by ref.Value's scheme prefix
+// ("env:", "file:", "vault:"); refs without a recognized prefix use fallback. It returns as
+// many resolved secrets as it can and a combined error for the ones it couldn't - a single
+// unresolvable secret shouldn't be silent, but also shouldn't be conflated with the ones that
+// did resolve.
+func Resolve(refs []protocol.SecretRef, providers map[string]Provider, fallback Provider) ([]Secret, error) {
+	var resolved []Secret
+	var errs []error
+
+	for _, ref := range refs {
+		scheme, provider := providerFor(ref, providers, fallback)
+		if provider == nil {
+			errs = append(errs, fmt.Errorf("secret %q: no provider for scheme %q", ref.Key, scheme))
+			continue
+		}
+		value, err := provider.Resolve(ref)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("secret %q: %w", ref.Key, err))
+			continue
+		}
+		resolved = append(resolved, Secret{Key: ref.Key, Value: value, Scope: ref.Scope})
+	}
+
+	if len(errs) > 0 {
+		return resolved, combineErrors(errs)
+	}
+	return resolved, nil
+}
+
+func providerFor(ref protocol.SecretRef, providers map[string]Provider, fallback Provider) (string, Provider) {
+	scheme, _ := splitScheme(ref.Value)
+	if p, ok := providers[scheme]; ok {
+		return scheme, p
+	}
+	return scheme, fallback
+}
+
+func splitScheme(locator string) (scheme, rest string) {
+	for i := 0; i < len(locator); i++ {
+		if locator[i] == ':' {
+			return locator[:i], locator[i+1:]
+		}
+	}
+	return "", locator
+}
+
+func combineErrors(errs []error) error {
+	msg := fmt.Sprintf("%d secret(s) failed to resolve:", len(errs))
+	for _, err := range errs {
+		msg += " " + err.Error() + ";"
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// EnvForCommand filters secrets down to the ones whose Scope allows them into commandName's
+// environment: an empty Scope is global, otherwise Scope must equal commandName. allowKeys, if
+// non-empty (a command's "allowSecrets" attribute), narrows the result further to only those
+// keys regardless of Scope - an explicit opt-in always wins.
+func EnvForCommand(all []Secret, commandName string, allowKeys []string) []string {
+	allow := make(map[string]bool, len(allowKeys))
+	for _, k := range allowKeys {
+		allow[k] = true
+	}
+
+	var env []string
+	for _, s := range all {
+		if len(allow) > 0 {
+			if allow[s.Key] {
+				env = append(env, s.Key+"="+s.Value)
+			}
+			continue
+		}
+		if s.Scope == "" || s.Scope == commandName {
+			env = append(env, s.Key+"="+s.Value)
+		}
+	}
+	return env
+}
+
+// Values extracts just the Value of each secret, e.g. to build a RedactingWriter's mask list.
+func Values(all []Secret) []string {
+	values := make([]string, 0, len(all))
+	for _, s := range all {
+		if s.Value != "" {
+			values = append(values, s.Value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,81 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package secrets
+
+import (
+	"testing"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+func TestResolve_UsesProviderForScheme(t *testing.T) {
+	t.Setenv("MY_TOKEN", "hunter2")
+	refs := []protocol.SecretRef{{Key: "token", Value: "env:MY_TOKEN"}}
+
+	resolved, err := Resolve(refs, map[string]Provider{"env": EnvProvider{}}, nil)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(resolved) != 1 || resolved[0].Value != "hunter2" {
+		t.Errorf("got %+v", resolved)
+	}
+}
+
+func TestResolve_FallsBackWhenSchemeUnknown(t *testing.T) {
+	t.Setenv("MY_TOKEN", "hunter2")
+	refs := []protocol.SecretRef{{Key: "token", Value: "MY_TOKEN"}}
+
+	resolved, err := Resolve(refs, nil, EnvProvider{})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(resolved) != 1 || resolved[0].Value != "hunter2" {
+		t.Errorf("got %+v", resolved)
+	}
+}
+
+func TestResolve_CollectsErrorsButReturnsWhatItCan(t *testing.T) {
+	t.Setenv("GOOD", "ok")
+	refs := []protocol.SecretRef{
+		{Key: "good", Value: "env:GOOD"},
+		{Key: "bad", Value: "env:MISSING_ENV_VAR"},
+	}
+
+	resolved, err := Resolve(refs, map[string]Provider{"env": EnvProvider{}}, nil)
+	if err == nil {
+		t.Fatal("expected an error for the unresolvable secret")
+	}
+	if len(resolved) != 1 || resolved[0].Key != "good" {
+		t.Errorf("got %+v", resolved)
+	}
+}
+
+func TestEnvForCommand_GlobalScopeAppliesToAnyCommand(t *testing.T) {
+	all := []Secret{{Key: "K", Value: "V", Scope: ""}}
+	env := EnvForCommand(all, "exec", nil)
+	if len(env) != 1 || env[0] != "K=V" {
+		t.Errorf("got %v", env)
+	}
+}
+
+func TestEnvForCommand_ScopedSecretExcludedFromOtherCommands(t *testing.T) {
+	all := []Secret{{Key: "K", Value: "V", Scope: "git"}}
+	if env := EnvForCommand(all, "exec", nil); len(env) != 0 {
+		t.Errorf("expected no secrets for exec, got %v", env)
+	}
+	if env := EnvForCommand(all, "git", nil); len(env) != 1 {
+		t.Errorf("expected the scoped secret for git, got %v", env)
+	}
+}
+
+func TestEnvForCommand_AllowListOverridesScope(t *testing.T) {
+	all := []Secret{
+		{Key: "A", Value: "1", Scope: "git"},
+		{Key: "B", Value: "2", Scope: "git"},
+	}
+	env := EnvForCommand(all, "exec", []string{"B"})
+	if len(env) != 1 || env[0] != "B=2" {
+		t.Errorf("got %v", env)
+	}
+}
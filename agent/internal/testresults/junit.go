@@ -0,0 +1,67 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package testresults
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// JUnitParser parses the de facto JUnit XML report format: a <testsuites> root (or a lone
+// <testsuite>) whose tests/failures/errors/skipped/time attributes give per-suite counts.
+type JUnitParser struct{}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+	junitCounts
+}
+
+type junitTestSuite struct {
+	XMLName xml.Name `xml:"testsuite"`
+	junitCounts
+}
+
+type junitCounts struct {
+	Tests    int     `xml:"tests,attr"`
+	Failures int     `xml:"failures,attr"`
+	Errors   int     `xml:"errors,attr"`
+	Skipped  int     `xml:"skipped,attr"`
+	Time     float64 `xml:"time,attr"`
+}
+
+func (c junitCounts) summary() Summary {
+	return Summary{
+		Total:    c.Tests,
+		Failed:   c.Failures + c.Errors,
+		Skipped:  c.Skipped,
+		Duration: time.Duration(c.Time * float64(time.Second)),
+	}
+}
+
+func (JUnitParser) Sniff(data []byte) bool {
+	return bytes.Contains(data, []byte("<testsuite"))
+}
+
+func (JUnitParser) Parse(data []byte) (Summary, error) {
+	var root junitTestSuites
+	if err := xml.Unmarshal(data, &root); err == nil && root.XMLName.Local == "testsuites" {
+		if len(root.TestSuites) == 0 {
+			return root.junitCounts.summary(), nil
+		}
+		var total Summary
+		for _, suite := range root.TestSuites {
+			total.Add(suite.junitCounts.summary())
+		}
+		return total, nil
+	}
+
+	var suite junitTestSuite
+	if err := xml.Unmarshal(data, &suite); err != nil {
+		return Summary{}, fmt.Errorf("junit: %w", err)
+	}
+	return suite.junitCounts.summary(), nil
+}
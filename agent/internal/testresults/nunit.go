@@ -0,0 +1,44 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package testresults
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// NUnitParser parses the NUnit 2 report format: a <test-results> root whose total/errors/
+// failures/not-run/skipped/ignored attributes give the suite's counts, and whose time attribute
+// is a plain seconds count (NUnit's "12.345", not JUnit's).
+type NUnitParser struct{}
+
+type nunitTestResults struct {
+	XMLName  xml.Name `xml:"test-results"`
+	Total    int      `xml:"total,attr"`
+	Errors   int      `xml:"errors,attr"`
+	Failures int      `xml:"failures,attr"`
+	NotRun   int      `xml:"not-run,attr"`
+	Skipped  int      `xml:"skipped,attr"`
+	Ignored  int      `xml:"ignored,attr"`
+	Time     float64  `xml:"time,attr"`
+}
+
+func (NUnitParser) Sniff(data []byte) bool {
+	return bytes.Contains(data, []byte("<test-results"))
+}
+
+func (NUnitParser) Parse(data []byte) (Summary, error) {
+	var r nunitTestResults
+	if err := xml.Unmarshal(data, &r); err != nil {
+		return Summary{}, fmt.Errorf("nunit: %w", err)
+	}
+	return Summary{
+		Total:    r.Total,
+		Failed:   r.Errors + r.Failures,
+		Skipped:  r.NotRun + r.Skipped + r.Ignored,
+		Duration: time.Duration(r.Time * float64(time.Second)),
+	}, nil
+}
@@ -0,0 +1,50 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package testresults
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// TAPParser parses the Test Anything Protocol: a "1..N" plan line followed by one "ok"/"not ok"
+// line per test, optionally suffixed with "# SKIP ..." or "# TODO ...". TAP carries no duration.
+type TAPParser struct{}
+
+var tapPlanLine = regexp.MustCompile(`^\s*1\.\.\d+\s*$`)
+var tapResultLine = regexp.MustCompile(`^\s*(not ok|ok)\b.*$`)
+var tapSkipDirective = regexp.MustCompile(`(?i)#\s*SKIP`)
+
+func (TAPParser) Sniff(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		return strings.HasPrefix(line, "TAP version") || tapPlanLine.MatchString(line) || tapResultLine.MatchString(line)
+	}
+	return false
+}
+
+func (TAPParser) Parse(data []byte) (Summary, error) {
+	var s Summary
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !tapResultLine.MatchString(line) {
+			continue
+		}
+		s.Total++
+		switch {
+		case tapSkipDirective.MatchString(line):
+			s.Skipped++
+		case strings.HasPrefix(strings.TrimSpace(line), "not ok"):
+			s.Failed++
+		}
+	}
+	return s, scanner.Err()
+}
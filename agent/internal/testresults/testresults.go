@@ -0,0 +1,103 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// testresults parses xUnit-style test report files produced by a build (JUnit XML, NUnit XML,
+// TAP) into a common Summary, mirroring the multi-format-backend approach of the testres project:
+// one Parser per format, selected by sniffing the file rather than trusting its extension.
+
+package testresults
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Summary aggregates one or more parsed test report files.
+type Summary struct {
+	Total    int           `json:"total"`
+	Failed   int           `json:"failed"`
+	Skipped  int           `json:"skipped"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Add accumulates other into s.
+func (s *Summary) Add(other Summary) {
+	s.Total += other.Total
+	s.Failed += other.Failed
+	s.Skipped += other.Skipped
+	s.Duration += other.Duration
+}
+
+// Parser parses a single test report file into a Summary.
+type Parser interface {
+	// Sniff reports whether data (the file's first few KiB is enough) looks like this Parser's
+	// format, so Collect can pick a Parser without trusting the file extension.
+	Sniff(data []byte) bool
+	// Parse parses the full file content into a Summary.
+	Parse(data []byte) (Summary, error)
+}
+
+// parsers is tried in order by ParserFor/Collect; JUnit first since it's by far the most common
+// format in the wild, TAP last since its Sniff is the least specific (plain text, no header).
+var parsers = []Parser{
+	JUnitParser{},
+	NUnitParser{},
+	TAPParser{},
+}
+
+// ParserFor returns the first registered Parser whose Sniff matches data, or nil if none do.
+func ParserFor(data []byte) Parser {
+	for _, p := range parsers {
+		if p.Sniff(data) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Collect scans workingDir for files matching each of globs (relative to workingDir, as declared
+// by a protocol.ArtifactPlan of type "unit"), parses every match with the format-appropriate
+// Parser, and returns the aggregate Summary plus the matched file paths (for the caller to upload
+// as raw artifacts via an ArtifactStore). A glob matching no files, or a file no Parser
+// recognizes, is skipped rather than failing the whole collection - a build's test-reports
+// directory legitimately varies run to run.
+func Collect(workingDir string, globs []string) (Summary, []string, error) {
+	var total Summary
+	var files []string
+	seen := map[string]bool{}
+
+	for _, glob := range globs {
+		matches, err := filepath.Glob(filepath.Join(workingDir, glob))
+		if err != nil {
+			return Summary{}, nil, fmt.Errorf("testresults: glob %q: %w", glob, err)
+		}
+		for _, path := range matches {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			summary, err := parseFile(path)
+			if err != nil {
+				continue
+			}
+			total.Add(summary)
+			files = append(files, path)
+		}
+	}
+
+	return total, files, nil
+}
+
+func parseFile(path string) (Summary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Summary{}, err
+	}
+	parser := ParserFor(data)
+	if parser == nil {
+		return Summary{}, fmt.Errorf("testresults: %s: unrecognized format", path)
+	}
+	return parser.Parse(data)
+}
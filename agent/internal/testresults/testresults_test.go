@@ -0,0 +1,92 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package testresults
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return data
+}
+
+func TestJUnitParser_SniffsAndParsesMultiSuiteReport(t *testing.T) {
+	data := readTestdata(t, "junit-suites.xml")
+	require.True(t, JUnitParser{}.Sniff(data))
+
+	summary, err := JUnitParser{}.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Total: 5, Failed: 2, Skipped: 1, Duration: 600 * time.Millisecond}, summary)
+}
+
+func TestJUnitParser_ParsesLoneTestSuiteReport(t *testing.T) {
+	data := readTestdata(t, "junit-single.xml")
+	require.True(t, JUnitParser{}.Sniff(data))
+
+	summary, err := JUnitParser{}.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Total: 1, Duration: 10 * time.Millisecond}, summary)
+}
+
+func TestNUnitParser_SniffsAndParses(t *testing.T) {
+	data := readTestdata(t, "nunit2.xml")
+	require.False(t, JUnitParser{}.Sniff(data))
+	require.True(t, NUnitParser{}.Sniff(data))
+
+	summary, err := NUnitParser{}.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Total: 5, Failed: 2, Skipped: 1, Duration: 1234 * time.Millisecond}, summary)
+}
+
+func TestTAPParser_SniffsAndParsesSkipDirective(t *testing.T) {
+	data := readTestdata(t, "results.tap")
+	require.True(t, TAPParser{}.Sniff(data))
+
+	summary, err := TAPParser{}.Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Total: 4, Failed: 2, Skipped: 1}, summary)
+}
+
+func TestParserFor_PicksParserForEachFormat(t *testing.T) {
+	assert.IsType(t, JUnitParser{}, ParserFor(readTestdata(t, "junit-suites.xml")))
+	assert.IsType(t, NUnitParser{}, ParserFor(readTestdata(t, "nunit2.xml")))
+	assert.IsType(t, TAPParser{}, ParserFor(readTestdata(t, "results.tap")))
+	assert.Nil(t, ParserFor([]byte("not a recognized report")))
+}
+
+func TestCollect_AggregatesAcrossGlobsAndSkipsUnrecognizedFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/a.xml", readTestdata(t, "junit-single.xml"), 0o644))
+	require.NoError(t, os.WriteFile(dir+"/b.xml", readTestdata(t, "nunit2.xml"), 0o644))
+	require.NoError(t, os.WriteFile(dir+"/notes.txt", []byte("not a test report"), 0o644))
+
+	summary, files, err := Collect(dir, []string{"*.xml", "*.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, Summary{Total: 6, Failed: 2, Skipped: 1, Duration: 1244 * time.Millisecond}, summary)
+	assert.ElementsMatch(t, []string{dir + "/a.xml", dir + "/b.xml"}, files)
+}
+
+func TestCollect_DedupsFilesMatchedByMultipleGlobs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/a.xml", readTestdata(t, "junit-single.xml"), 0o644))
+
+	_, files, err := Collect(dir, []string{"*.xml", "a.*"})
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestCollect_NoMatchesReturnsEmptySummary(t *testing.T) {
+	summary, files, err := Collect(t.TempDir(), []string{"test-reports/**/*.xml"})
+	require.NoError(t, err)
+	assert.Equal(t, Summary{}, summary)
+	assert.Empty(t, files)
+}
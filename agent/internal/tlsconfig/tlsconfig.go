@@ -0,0 +1,68 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// tlsconfig builds the *tls.Config the registrar hands out (registration.Registrar's
+// createTLSConfig) - used by the registrar's own HTTP client, the WebSocket dialer
+// (internal/websocket.Connect), and the gRPC transport - so a server CA or client certificate
+// only has to be loaded, and kept current, in one place. The remoting get_work client
+// (internal/remoting.Client) is not configured from it.
+
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+)
+
+// Load builds a *tls.Config trusting cfg.GoServerCAFile() plus any cfg.ExtraCAFileList() bundles
+// (e.g. a TLS-terminating proxy's CA that the GoCD server itself doesn't know about). When
+// withClientCert is true, it also presents cfg.AgentCertFile()/AgentPrivateKeyFile() as the
+// client certificate - GoCD's mutual-TLS requirement for the remoting and WebSocket APIs once an
+// agent is registered.
+func Load(cfg *config.Config, withClientCert bool) (*tls.Config, error) {
+	roots, err := certPool(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{RootCAs: roots}
+
+	if withClientCert {
+		cert, err := tls.LoadX509KeyPair(cfg.AgentCertFile(), cfg.AgentPrivateKeyFile())
+		if err != nil {
+			return nil, fmt.Errorf("tlsconfig: load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// certPool loads cfg.GoServerCAFile() plus every bundle in cfg.ExtraCAFileList() into one
+// x509.CertPool.
+func certPool(cfg *config.Config) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+
+	caCert, err := os.ReadFile(cfg.GoServerCAFile())
+	if err != nil {
+		return nil, fmt.Errorf("tlsconfig: read server CA: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("tlsconfig: no certificates found in %s", cfg.GoServerCAFile())
+	}
+
+	for _, path := range cfg.ExtraCAFileList() {
+		extra, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("tlsconfig: read extra CA %s: %w", path, err)
+		}
+		if !pool.AppendCertsFromPEM(extra) {
+			return nil, fmt.Errorf("tlsconfig: no certificates found in %s", path)
+		}
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,176 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA is a minimal in-memory CA, used to issue a server leaf cert and check it can (or can't)
+// be verified against the pool Load builds.
+type testCA struct {
+	key  *ecdsa.PrivateKey
+	cert *x509.Certificate
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, commonName string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{key: key, cert: cert, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issueLeaf issues a server-auth leaf certificate for 127.0.0.1, signed by ca.
+func (ca *testCA) issueLeaf(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func testConfig(t *testing.T) *config.Config {
+	dir := t.TempDir()
+	return &config.Config{ConfigDir: dir}
+}
+
+// serveOnce starts a TLS listener presenting serverCert, completes exactly one handshake (so the
+// client side doesn't race a bare Accept+Close into an EOF instead of a real verify result), then
+// closes the connection.
+func serveOnce(t *testing.T, serverCert tls.Certificate) string {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}})
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if tlsConn, ok := conn.(*tls.Conn); ok {
+			_ = tlsConn.Handshake()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestLoad_TrustsServerCA(t *testing.T) {
+	serverCA := newTestCA(t, "go-server-ca")
+	cfg := testConfig(t)
+	require.NoError(t, os.WriteFile(cfg.GoServerCAFile(), serverCA.pem, 0644))
+
+	tlsConfig, err := Load(cfg, false)
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig.Certificates)
+
+	tlsConfig.ServerName = "127.0.0.1"
+	addr := serveOnce(t, serverCA.issueLeaf(t))
+	conn, err := tls.Dial("tcp", addr, tlsConfig)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestLoad_TrustsExtraCAFiles(t *testing.T) {
+	serverCA := newTestCA(t, "go-server-ca")
+	proxyCA := newTestCA(t, "proxy-ca")
+	cfg := testConfig(t)
+	require.NoError(t, os.WriteFile(cfg.GoServerCAFile(), serverCA.pem, 0644))
+
+	extraPath := filepath.Join(cfg.ConfigDir, "proxy-ca.pem")
+	require.NoError(t, os.WriteFile(extraPath, proxyCA.pem, 0644))
+	cfg.ExtraCAFiles = extraPath
+
+	tlsConfig, err := Load(cfg, false)
+	require.NoError(t, err)
+
+	// A leaf signed by the extra CA (not the primary server CA) must still verify.
+	tlsConfig.ServerName = "127.0.0.1"
+	addr := serveOnce(t, proxyCA.issueLeaf(t))
+	conn, err := tls.Dial("tcp", addr, tlsConfig)
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestLoad_MissingServerCAIsAnError(t *testing.T) {
+	cfg := testConfig(t)
+	_, err := Load(cfg, false)
+	assert.Error(t, err)
+}
+
+func TestLoad_MissingExtraCAFileIsAnError(t *testing.T) {
+	serverCA := newTestCA(t, "go-server-ca")
+	cfg := testConfig(t)
+	require.NoError(t, os.WriteFile(cfg.GoServerCAFile(), serverCA.pem, 0644))
+	cfg.ExtraCAFiles = filepath.Join(cfg.ConfigDir, "does-not-exist.pem")
+
+	_, err := Load(cfg, false)
+	assert.Error(t, err)
+}
+
+func TestLoad_WithClientCertLoadsKeyPair(t *testing.T) {
+	serverCA := newTestCA(t, "go-server-ca")
+	cfg := testConfig(t)
+	require.NoError(t, os.WriteFile(cfg.GoServerCAFile(), serverCA.pem, 0644))
+
+	agentCA := newTestCA(t, "agent")
+	agentCert := agentCA.issueLeaf(t)
+	require.NoError(t, os.WriteFile(cfg.AgentCertFile(), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: agentCert.Certificate[0]}), 0644))
+	keyDER, err := x509.MarshalECPrivateKey(agentCert.PrivateKey.(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cfg.AgentPrivateKeyFile(), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0644))
+
+	tlsConfig, err := Load(cfg, true)
+	require.NoError(t, err)
+	require.Len(t, tlsConfig.Certificates, 1)
+}
+
+func TestLoad_WithClientCertMissingKeyPairIsAnError(t *testing.T) {
+	serverCA := newTestCA(t, "go-server-ca")
+	cfg := testConfig(t)
+	require.NoError(t, os.WriteFile(cfg.GoServerCAFile(), serverCA.pem, 0644))
+
+	_, err := Load(cfg, true)
+	assert.Error(t, err)
+}
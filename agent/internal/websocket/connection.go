@@ -10,13 +10,18 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/d-led/ex_gocd/agent/internal/config"
+	"github.com/d-led/ex_gocd/agent/internal/remoting/transport"
 	"github.com/d-led/ex_gocd/agent/pkg/protocol"
 	"github.com/gorilla/websocket"
 )
 
+// Connection implements transport.Transport: the default AGENT_TRANSPORT=ws path.
+var _ transport.Transport = (*Connection)(nil)
+
 // Connection wraps the WebSocket connection and handles message routing
 type Connection struct {
 	conn       *websocket.Conn
@@ -27,6 +32,9 @@ type Connection struct {
 	send       chan *protocol.Message
 	receive    chan *protocol.Message
 	done       chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
 }
 
 // Connect establishes a WebSocket connection to the server
@@ -84,10 +92,23 @@ func (c *Connection) Receive() <-chan *protocol.Message {
 	return c.receive
 }
 
-// Close closes the WebSocket connection
+// Close closes the WebSocket connection. Safe to call more than once (writePump also calls
+// shutdown internally after an unrecoverable write error).
 func (c *Connection) Close() error {
-	close(c.done)
-	return c.conn.Close()
+	c.shutdown()
+	return c.closeErr
+}
+
+// shutdown closes done and the underlying connection, exactly once. writePump calls this on a
+// write/ping error instead of just returning: without it, a transient write failure left the
+// pump dead while c.send and c.done stayed open, so Send would keep queuing messages into a
+// channel nothing drains - silently stalling every future console log upload (or any other
+// queued message) until something else happened to call Close.
+func (c *Connection) shutdown() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.closeErr = c.conn.Close()
+	})
 }
 
 // SetCookie stores the session cookie from server
@@ -150,12 +171,14 @@ func (c *Connection) writePump() {
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if err := c.conn.WriteJSON(msg); err != nil {
 				log.Printf("WebSocket write error: %v", err)
+				c.shutdown()
 				return
 			}
-			
+
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.shutdown()
 				return
 			}
 			
@@ -0,0 +1,102 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// Helper package for writing out-of-process executor plugins for the agent (see
+// internal/executor/plugin): a small binary that answers --describe with a JSON manifest and,
+// on "run", executes one BuildCommand fed as a JSON envelope on stdin, reporting back via
+// newline-delimited JSON events on stdout.
+
+package executorplugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/d-led/ex_gocd/agent/pkg/protocol"
+)
+
+// Event types a plugin reports on stdout.
+const (
+	EventLog    = "log"
+	EventStatus = "status"
+	EventError  = "error"
+)
+
+// Manifest describes a plugin: the commands it advertises and answers --describe with.
+type Manifest struct {
+	Name     string                 `json:"name"`
+	Version  string                 `json:"version"`
+	Commands []string               `json:"commands"`
+	Schema   map[string]interface{} `json:"schema,omitempty"`
+}
+
+// Envelope is written once, as JSON, to a plugin's stdin when the agent runs it with "run".
+type Envelope struct {
+	WorkingDir string                 `json:"workingDir"`
+	Env        []string               `json:"env"`
+	Command    *protocol.BuildCommand `json:"command"`
+}
+
+// Event is one line of newline-delimited JSON a plugin writes to stdout while handling a run.
+type Event struct {
+	Type    string `json:"type"` // EventLog, EventStatus, or EventError
+	Message string `json:"message,omitempty"`
+}
+
+// Handler executes one BuildCommand. Each line passed to emit becomes an EventLog event on
+// stdout; a non-nil return becomes a single EventError event and a non-zero plugin exit code.
+type Handler func(env *Envelope, emit func(line string)) error
+
+// Serve is the entire main() of a plugin binary: it dispatches os.Args[1] ("--describe" or
+// "run") and exits the process. handlers maps each BuildCommand.Name the plugin supports to the
+// Handler that runs it; manifest.Commands should list the same names.
+func Serve(manifest Manifest, handlers map[string]Handler) {
+	os.Exit(serve(manifest, handlers, os.Args, os.Stdin, os.Stdout))
+}
+
+func serve(manifest Manifest, handlers map[string]Handler, args []string, stdin *os.File, stdout *os.File) int {
+	if len(args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: --describe | run")
+		return 2
+	}
+
+	switch args[1] {
+	case "--describe":
+		if err := json.NewEncoder(stdout).Encode(manifest); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+
+	case "run":
+		var env Envelope
+		if err := json.NewDecoder(stdin).Decode(&env); err != nil {
+			fmt.Fprintln(os.Stderr, "decode envelope:", err)
+			return 1
+		}
+		if env.Command == nil {
+			fmt.Fprintln(os.Stderr, "envelope missing command")
+			return 1
+		}
+		handler := handlers[env.Command.Name]
+		if handler == nil {
+			fmt.Fprintf(os.Stderr, "no handler for command %q\n", env.Command.Name)
+			return 1
+		}
+
+		enc := json.NewEncoder(stdout)
+		emit := func(line string) {
+			enc.Encode(Event{Type: EventLog, Message: line})
+		}
+		if err := handler(&env, emit); err != nil {
+			enc.Encode(Event{Type: EventError, Message: err.Error()})
+			return 1
+		}
+		enc.Encode(Event{Type: EventStatus, Message: "ok"})
+		return 0
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown subcommand %q\n", args[1])
+		return 2
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const binaryPrefix = "gocd-task-plugin-"
+
+// Launcher is a TaskPlugin backed by a subprocess speaking the GoCD Plugin JSON message API v1
+// over stdin/stdout: one JSON PluginRequest line in, one JSON PluginResponse line out, per run.
+type Launcher struct {
+	Path string
+}
+
+// Discover finds the task plugin binary for pluginID in dir (a file named
+// gocd-task-plugin-<pluginID>). Unlike executor/plugin.Discover's directory-wide scan, a
+// PluggableTaskBuilder already names the one plugin its step depends on, so a missing dir or
+// binary is reported as an error rather than silently skipped.
+func Discover(dir, pluginID string) (*Launcher, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("plugins: no task plugins directory configured")
+	}
+	path := filepath.Join(dir, binaryPrefix+pluginID)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("plugins: task plugin %q: %w", pluginID, err)
+	}
+	return &Launcher{Path: path}, nil
+}
+
+// Execute starts the plugin subprocess, writes request as a single JSON line on stdin, and reads
+// back a single JSON PluginResponse line from stdout. Canceling ctx kills the subprocess.
+func (l *Launcher) Execute(ctx context.Context, request PluginRequest) (PluginResult, error) {
+	c := exec.CommandContext(ctx, l.Path)
+	stdin, err := c.StdinPipe()
+	if err != nil {
+		return PluginResult{}, fmt.Errorf("plugins: stdin pipe: %w", err)
+	}
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return PluginResult{}, fmt.Errorf("plugins: stdout pipe: %w", err)
+	}
+	c.Stderr = os.Stderr
+
+	if err := c.Start(); err != nil {
+		return PluginResult{}, fmt.Errorf("plugins: start %s: %w", l.Path, err)
+	}
+
+	encodeErr := json.NewEncoder(stdin).Encode(request)
+	stdin.Close()
+	if encodeErr != nil {
+		_ = c.Process.Kill()
+		_ = c.Wait()
+		return PluginResult{}, fmt.Errorf("plugins: write request: %w", encodeErr)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var response PluginResponse
+	var respErr error
+	if scanner.Scan() {
+		respErr = json.Unmarshal(scanner.Bytes(), &response)
+	} else {
+		respErr = scanner.Err()
+		if respErr == nil {
+			respErr = fmt.Errorf("no response")
+		}
+	}
+
+	if waitErr := c.Wait(); waitErr != nil && respErr == nil {
+		respErr = waitErr
+	}
+	if respErr != nil {
+		return PluginResult{}, fmt.Errorf("plugins: %s: %w", l.Path, respErr)
+	}
+
+	return toResult(response), nil
+}
+
+// toResult translates a raw PluginResponse into a PluginResult: a non-success response code is
+// always a Failed result, even if the plugin forgot to set "success":false in its body.
+func toResult(response PluginResponse) PluginResult {
+	var body struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+	}
+	if len(response.Body) > 0 {
+		_ = json.Unmarshal(response.Body, &body)
+	}
+	if response.Code != ResponseSuccess {
+		return PluginResult{Success: false, Message: body.Message}
+	}
+	if len(response.Body) == 0 {
+		body.Success = true
+	}
+	return PluginResult{Success: body.Success, Message: body.Message}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// buildFakePlugin compiles testdata/plugins/fakeplugin into dir, named the way Discover expects
+// (gocd-task-plugin-<pluginID>).
+func buildFakePlugin(t *testing.T, dir, pluginID string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("getwd: %v", err)
+	}
+	name := "gocd-task-plugin-" + pluginID
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	cmd := exec.Command("go", "build", "-o", filepath.Join(dir, name), "./testdata/plugins/fakeplugin")
+	cmd.Dir = wd
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("build fake plugin: %v\n%s", err, out)
+	}
+}
+
+func TestLauncher_Execute_Succeeds(t *testing.T) {
+	dir := t.TempDir()
+	buildFakePlugin(t, dir, "fake")
+
+	l, err := Discover(dir, "fake")
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"outcome": "ok"})
+	result, err := l.Execute(context.Background(), PluginRequest{Name: "execute", Body: body})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("Success = false, want true (message %q)", result.Message)
+	}
+}
+
+func TestLauncher_Execute_ReportsTaskFailure(t *testing.T) {
+	dir := t.TempDir()
+	buildFakePlugin(t, dir, "fake")
+
+	l, err := Discover(dir, "fake")
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"outcome": "fail"})
+	result, err := l.Execute(context.Background(), PluginRequest{Name: "execute", Body: body})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if result.Success {
+		t.Error("Success = true, want false")
+	}
+	if result.Message != "simulated task failure" {
+		t.Errorf("Message = %q, want %q", result.Message, "simulated task failure")
+	}
+}
+
+func TestLauncher_Execute_SurfacesPluginCrash(t *testing.T) {
+	dir := t.TempDir()
+	buildFakePlugin(t, dir, "fake")
+
+	l, err := Discover(dir, "fake")
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"outcome": "error"})
+	_, err = l.Execute(context.Background(), PluginRequest{Name: "execute", Body: body})
+	if err == nil {
+		t.Error("Execute: want error for a plugin that exits without answering")
+	}
+}
+
+func TestDiscover_MissingBinaryIsAnError(t *testing.T) {
+	if _, err := Discover(t.TempDir(), "does-not-exist"); err == nil {
+		t.Error("Discover: want error for a missing plugin binary")
+	}
+}
+
+func TestDiscover_EmptyDirIsAnError(t *testing.T) {
+	if _, err := Discover("", "fake"); err == nil {
+		t.Error("Discover: want error for an unconfigured task plugins directory")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright © 2026 ex_gocd
+// Licensed under the Apache License, Version 2.0
+// TaskPlugin and the GoCD Plugin JSON message API v1 request/response shapes it speaks -
+// distinct from agent/pkg/executorplugin, which implements this repo's own, simpler protocol
+// for the executor plugins discovered under AGENT_PLUGIN_DIR.
+
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Message API v1 response codes, per GoCD's go.processor/go.task.* contract.
+const (
+	ResponseSuccess          = 200
+	ResponseValidationFailed = 400
+	ResponseInternalError    = 500
+)
+
+// PluginRequest is one message sent to a task plugin, following the GoCD Plugin JSON message API
+// v1: Name is the request name (e.g. "go.plugin-settings.get-view", "execute", "validate"), Body
+// is the request-specific JSON payload.
+type PluginRequest struct {
+	Name string          `json:"requestName"`
+	Body json.RawMessage `json:"requestBody,omitempty"`
+}
+
+// PluginResponse is the raw message a task plugin writes back: Code follows the message API's
+// response-code convention above; Body is the response-specific JSON payload.
+type PluginResponse struct {
+	Code int             `json:"responseCode"`
+	Body json.RawMessage `json:"responseBody,omitempty"`
+}
+
+// PluginResult is what an "execute" request resolves to, once PluginResponse.Body
+// ({"success":bool,"message":string}) is parsed - executor.PluginExec translates Success into
+// Passed/Failed the same way the other executors translate a process exit code.
+type PluginResult struct {
+	Success bool
+	Message string
+}
+
+// TaskPlugin executes GoCD Plugin JSON message API v1 requests against a task plugin.
+type TaskPlugin interface {
+	Execute(ctx context.Context, request PluginRequest) (PluginResult, error)
+}
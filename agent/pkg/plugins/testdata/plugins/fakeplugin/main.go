@@ -0,0 +1,55 @@
+// Command fakeplugin is a stand-in GoCD task plugin for launcher_test.go: it speaks the subset
+// of the GoCD Plugin JSON message API v1 Launcher.Execute uses (one PluginRequest JSON line on
+// stdin, one PluginResponse JSON line on stdout). Its config's "outcome" key controls behavior:
+// "error" exits non-zero without answering at all, "fail" answers success:false, anything else
+// answers success:true and echoes the config back in its message.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type request struct {
+	Name string          `json:"requestName"`
+	Body json.RawMessage `json:"requestBody,omitempty"`
+}
+
+type response struct {
+	Code int         `json:"responseCode"`
+	Body interface{} `json:"responseBody,omitempty"`
+}
+
+func main() {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	if !scanner.Scan() {
+		os.Exit(1)
+	}
+	var req request
+	if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+		fmt.Fprintln(os.Stderr, "fakeplugin: bad request:", err)
+		os.Exit(1)
+	}
+
+	var config map[string]string
+	_ = json.Unmarshal(req.Body, &config)
+
+	switch config["outcome"] {
+	case "error":
+		fmt.Fprintln(os.Stderr, "fakeplugin: simulated crash")
+		os.Exit(1)
+	case "fail":
+		_ = json.NewEncoder(os.Stdout).Encode(response{
+			Code: 200,
+			Body: map[string]interface{}{"success": false, "message": "simulated task failure"},
+		})
+	default:
+		_ = json.NewEncoder(os.Stdout).Encode(response{
+			Code: 200,
+			Body: map[string]interface{}{"success": true, "message": fmt.Sprintf("ran with config %v", config)},
+		})
+	}
+}
@@ -19,8 +19,43 @@ const (
 	ReportCurrentStatusAction = "reportCurrentStatus"
 	ReportCompletingAction    = "reportCompleting"
 	ReportCompletedAction     = "reportCompleted"
+	AppendConsoleLogAction    = "appendConsoleLog"
+	RejectBuildAction         = "rejectBuild"
 )
 
+// Console log stream identifiers for LogLine.Out.
+const (
+	LogLineStdout = "stdout"
+	LogLineStderr = "stderr"
+)
+
+// BuildCommand.Name values dispatched by executor.Registry.
+const (
+	CommandCompose  = "compose"
+	CommandExec     = "exec"
+	CommandGit      = "git"
+	CommandUpload   = "upload"
+	CommandDownload = "download"
+	// CommandFetch downloads a URL (a published artifact or an arbitrary file) to Dest,
+	// optionally verifying Checksum - see remoting/builders and executor.Fetch.
+	CommandFetch = "fetch"
+	// CommandPluginExec runs a GoCD Pluggable Task plugin, with Attributes["pluginId"] naming
+	// the plugin and Attributes["config"] (map[string]string) its task configuration - see
+	// remoting/builders.PluggableTaskBuilder, agent/pkg/plugins, and executor.PluginExec.
+	CommandPluginExec = "pluginExec"
+)
+
+// LogLine is a single line of build console output, batched and sent to the server via
+// AppendConsoleLogAction instead of one HTTP POST per line.
+type LogLine struct {
+	BuildId string `json:"buildId"`
+	Proc    string `json:"proc"`          // name of the BuildCommand this line came from
+	Time    int64  `json:"time"`          // unix millis
+	Pos     int    `json:"pos"`           // monotonically increasing per build
+	Out     string `json:"out"`           // LogLineStdout or LogLineStderr
+	Msg     string `json:"msg"`
+}
+
 // Message is the base protocol message for WebSocket communication
 type Message struct {
 	Action      string          `json:"action"`
@@ -55,6 +90,11 @@ type AgentRuntimeInfo struct {
 	ElasticPluginId              string             `json:"elasticPluginId,omitempty"`
 	ElasticAgentId               string             `json:"elasticAgentId,omitempty"`
 	SupportsBuildCommandProtocol bool               `json:"supportsBuildCommandProtocol"`
+	// Resources and Environments let the server route a Build to only the agents that declare
+	// them (see config.Config.ResourceList/EnvironmentList); Resources always includes an
+	// implicit "platform:GOOS/GOARCH" entry.
+	Resources    []string `json:"resources,omitempty"`
+	Environments []string `json:"environments,omitempty"`
 }
 
 // Build represents a job to execute
@@ -66,6 +106,53 @@ type Build struct {
 	ArtifactUploadBaseUrl   string         `json:"artifactUploadBaseUrl"`
 	PropertyBaseUrl         string         `json:"propertyBaseUrl,omitempty"`
 	BuildCommand            *BuildCommand  `json:"buildCommand"`
+	// Secrets lists the job's secret references to resolve before running BuildCommand; the
+	// server never sends secret values over the wire, only where to fetch them - see
+	// agent/internal/secrets.
+	Secrets []SecretRef `json:"secrets,omitempty"`
+	// RequiredResources and Labels gate which agent is allowed to run this Build: every entry
+	// must appear in the agent's AgentRuntimeInfo.Resources (RequiredResources) or in either its
+	// Resources or Environments (Labels). Empty means any agent qualifies. See
+	// Agent.matchesBuild.
+	RequiredResources []string `json:"requiredResources,omitempty"`
+	Labels            []string `json:"labels,omitempty"`
+	// ArtifactPlans declares where build-produced artifacts live and what happens to them once
+	// the build's command finishes - see ArtifactPlan and internal/testresults.Collect.
+	ArtifactPlans []ArtifactPlan `json:"artifactPlans,omitempty"`
+}
+
+// ArtifactPlan is one artifactsPlans entry of a Build: Src is a glob (relative to the build's
+// working dir) of files to publish, Dest is the server-side path to publish them under, and Type
+// distinguishes a plain file plan ("file", the default) from a test report plan ("unit") whose
+// matches are parsed into a TestResultsSummary rather than just uploaded.
+type ArtifactPlan struct {
+	Src  string `json:"src"`
+	Dest string `json:"dest,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
+// ArtifactPlanTypeUnit marks an ArtifactPlan whose Src glob selects xUnit-style test report files
+// (JUnit/NUnit/TAP) to be parsed into a TestResultsSummary instead of, or in addition to, being
+// uploaded as-is.
+const ArtifactPlanTypeUnit = "unit"
+
+// TestResultsSummary is the aggregate of every test report file a build's "unit" ArtifactPlans
+// matched - see internal/testresults.Collect, which produces it from parsed JUnit/NUnit/TAP files.
+type TestResultsSummary struct {
+	Total      int   `json:"total"`
+	Failed     int   `json:"failed"`
+	Skipped    int   `json:"skipped"`
+	DurationMs int64 `json:"durationMs"`
+}
+
+// SecretRef is a pointer to a secret value the agent must resolve itself via a
+// secrets.Provider, rather than a value sent by the server. Scope limits which BuildCommand the
+// resolved value is injected into: a command name (e.g. "exec"), or "" to allow it into every
+// command's environment.
+type SecretRef struct {
+	Key   string `json:"key"`
+	Value string `json:"value"` // provider-specific locator, e.g. an env var name, file path, or Vault path
+	Scope string `json:"scope,omitempty"`
 }
 
 // BuildCommand contains the tasks to execute
@@ -82,21 +169,31 @@ type BuildCommand struct {
 	Dest         string                   `json:"dest,omitempty"`
 	URL          string                   `json:"url,omitempty"`
 	Branch       string                   `json:"branch,omitempty"`
+	// Checksum is an optional "algo:hex" content hash (e.g. "sha256:...") CommandFetch verifies
+	// the downloaded file against before reporting success.
+	Checksum     string                   `json:"checksum,omitempty"`
 	Attributes   map[string]interface{}   `json:"attributes,omitempty"`
 }
 
-// Report contains job execution status  
+// Report contains job execution status
 type Report struct {
 	BuildId          string            `json:"buildId"`
 	Result           string            `json:"result,omitempty"` // "Passed", "Failed", "Cancelled"
 	JobState         string            `json:"jobState,omitempty"`
 	AgentRuntimeInfo *AgentRuntimeInfo `json:"agentRuntimeInfo"`
+	// TestResults is set on the Completed report when the build declared "unit" ArtifactPlans -
+	// see internal/testresults.Collect.
+	TestResults *TestResultsSummary `json:"testResults,omitempty"`
 }
 
 // Registration response from server
 type Registration struct {
 	AgentPrivateKey  string `json:"agentPrivateKey,omitempty"`
 	AgentCertificate string `json:"agentCertificate,omitempty"`
+	// CertificateChain is returned for CSR-based enrollment: the signed agent certificate
+	// (optionally followed by intermediates), PEM-concatenated. The server never generates
+	// or transmits a private key in this flow.
+	CertificateChain string `json:"certificateChain,omitempty"`
 }
 
 // Helper methods to extract typed data from Message
@@ -125,8 +222,34 @@ func (m *Message) DataString() string {
 	return s
 }
 
+// BuildIdFromData decodes a cancelBuild message's {"buildId": "..."} payload.
+func (m *Message) BuildIdFromData() string {
+	var payload struct {
+		BuildId string `json:"buildId"`
+	}
+	json.Unmarshal(m.Data, &payload)
+	return payload.BuildId
+}
+
+// LogLines decodes an appendConsoleLog message's batch of LogLine.
+func (m *Message) LogLines() []LogLine {
+	var lines []LogLine
+	json.Unmarshal(m.Data, &lines)
+	return lines
+}
+
 // Message constructors
 
+// JoinMessage is the initial "phx_join" message the agent sends once per connection to
+// establish the Phoenix channel the server's WebSocket protocol is built on (see Agent.sendJoin).
+func JoinMessage(info *AgentRuntimeInfo) *Message {
+	data, _ := json.Marshal(info)
+	return &Message{
+		Action: "phx_join",
+		Data:   data,
+	}
+}
+
 func PingMessage(info *AgentRuntimeInfo) *Message {
 	data, _ := json.Marshal(info)
 	return &Message{
@@ -161,3 +284,30 @@ func ReportCompletingMessage(report *Report) *Message {
 func ReportCurrentStatusMessage(report *Report) *Message {
 	return ReportMessage(ReportCurrentStatusAction, report)
 }
+
+// AppendConsoleLogMessage batches lines into a single appendConsoleLog message.
+func AppendConsoleLogMessage(lines []LogLine) *Message {
+	data, _ := json.Marshal(lines)
+	return &Message{
+		Action: AppendConsoleLogAction,
+		Data:   data,
+	}
+}
+
+// RejectBuild is the payload of a RejectBuildAction reply: which Build was rejected and why,
+// so the server can route it to a different, capable agent instead of waiting on one that will
+// never start it.
+type RejectBuild struct {
+	BuildId string `json:"buildId"`
+	Reason  string `json:"reason"`
+}
+
+// RejectBuildMessage replies to a BuildAction whose RequiredResources/Labels the agent doesn't
+// satisfy (see Agent.matchesBuild), instead of silently ignoring or failing the build.
+func RejectBuildMessage(buildId, reason string) *Message {
+	data, _ := json.Marshal(RejectBuild{BuildId: buildId, Reason: reason})
+	return &Message{
+		Action: RejectBuildAction,
+		Data:   data,
+	}
+}